@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -35,7 +36,12 @@ func ParseAddress(address string) (common.Address, error) {
 	return common.Address{}, fmt.Errorf("invalid address: %v", address)
 }
 
+// GetConfiguredPrivateKey 从配置的助记词或私钥得到私钥，各参数可以是 secret://<scheme>/<key> 形式的密钥引用
 func GetConfiguredPrivateKey(mnemonic, hdPath, privKeyStr, password string) (*ecdsa.PrivateKey, error) {
+	if err := resolveSecrets(context.Background(), &mnemonic, &privKeyStr, &password); err != nil {
+		return nil, err
+	}
+	RegisterSecret(mnemonic, privKeyStr, password)
 
 	useMnemonic := mnemonic != "" && hdPath != ""
 	usePrivKeyStr := privKeyStr != ""
@@ -63,6 +69,11 @@ func (f fakeNetworkParams) HDPubKeyVersion() [4]byte {
 }
 
 func DerivePrivateKey(mnemonic, hdPath, password string) (*ecdsa.PrivateKey, error) {
+	if err := resolveSecrets(context.Background(), &mnemonic, &password); err != nil {
+		return nil, err
+	}
+	RegisterSecret(mnemonic, password)
+
 	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
 	if err != nil {
 		return nil, err
@@ -93,7 +104,13 @@ func DerivePrivateKey(mnemonic, hdPath, password string) (*ecdsa.PrivateKey, err
 	return crypto.ToECDSA(rawPrivKey)
 }
 
+// ParsePrivateKeyStr 解析十六进制私钥，privKeyStr 可以是 secret://<scheme>/<key> 形式的密钥引用
 func ParsePrivateKeyStr(privKeyStr string) (*ecdsa.PrivateKey, error) {
+	if err := resolveSecrets(context.Background(), &privKeyStr); err != nil {
+		return nil, err
+	}
+	RegisterSecret(privKeyStr)
+
 	hex := strings.TrimPrefix(privKeyStr, "0x")
 	return crypto.HexToECDSA(hex)
 }
@@ -118,10 +135,17 @@ func ParseWalletPrivKeyAndContractAddr(name string, mnemonic string, hdPath stri
 	return privKey, contractAddress, nil
 }
 
+// PrivateKeySignerFn 返回使用私钥签名的 SignerFn，使用零值策略，即只拒绝 EIP-7702 交易，
+// 需要更严格的限制时使用 PrivateKeySignerFnWithPolicy
 func PrivateKeySignerFn(key *ecdsa.PrivateKey, chainID *big.Int) bind.SignerFn {
+	return PrivateKeySignerFnWithPolicy(key, chainID, SignerPolicy{})
+}
+
+// PrivateKeySignerFnWithPolicy 返回使用私钥签名的 SignerFn，签名前按 policy 检查交易
+func PrivateKeySignerFnWithPolicy(key *ecdsa.PrivateKey, chainID *big.Int, policy SignerPolicy) bind.SignerFn {
 	from := crypto.PubkeyToAddress(key.PublicKey)
 	signer := types.LatestSignerForChainID(chainID)
-	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	return WithSignerPolicy(func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
 		if address != from {
 			return nil, bind.ErrNotAuthorized
 		}
@@ -130,11 +154,26 @@ func PrivateKeySignerFn(key *ecdsa.PrivateKey, chainID *big.Int) bind.SignerFn {
 			return nil, err
 		}
 		return tx.WithSignature(signer, signature)
-	}
+	}, policy)
 }
 
+// NewHSMTransactOpts 返回使用 HSM 签名的 TransactOpts，使用零值策略，即只拒绝 EIP-7702 交易
 func NewHSMTransactOpts(ctx context.Context, hsmAPIName string, hsmAddress string, chainID *big.Int, hsmCreden string) (*bind.TransactOpts, error) {
+	return NewHSMTransactOptsWithPolicy(ctx, hsmAPIName, hsmAddress, chainID, hsmCreden, SignerPolicy{})
+}
+
+// NewHSMTransactOptsWithPolicy 返回使用 HSM 签名的 TransactOpts，签名前按 policy 检查交易，
+// hsmCreden 可以是 secret://<scheme>/<key> 形式的密钥引用
+func NewHSMTransactOptsWithPolicy(ctx context.Context, hsmAPIName string, hsmAddress string, chainID *big.Int, hsmCreden string, policy SignerPolicy) (*bind.TransactOpts, error) {
+	if err := resolveSecrets(ctx, &hsmCreden); err != nil {
+		return nil, err
+	}
+	RegisterSecret(hsmCreden)
 	proBytes, err := hex.DecodeString(hsmCreden)
+	if err != nil {
+		return nil, fmt.Errorf("decode hsm credentials: %w", err)
+	}
+	registerCredentialsJSON(proBytes)
 	apikey := option.WithCredentialsJSON(proBytes)
 	client, err := kms.NewKeyManagementClient(ctx, apikey)
 	if err != nil {
@@ -146,5 +185,29 @@ func NewHSMTransactOpts(ctx context.Context, hsmAPIName string, hsmAddress strin
 		Gclient:      client,
 	}
 	opts, err := mk.NewEthereumTransactorrWithChainID(ctx, chainID)
-	return opts, nil
+	if err != nil {
+		return nil, err
+	}
+	return ApplySignerPolicy(opts, policy), nil
+}
+
+// registerCredentialsJSON 登记 HSM 凭证 JSON 中的私钥等敏感字段
+func registerCredentialsJSON(credentials []byte) {
+	var fields map[string]any
+	if err := json.Unmarshal(credentials, &fields); err != nil {
+		return
+	}
+	for key, value := range fields {
+		s, ok := value.(string)
+		if !ok || !isSensitiveKey(key) {
+			continue
+		}
+		RegisterSecret(s)
+		// PEM 私钥在日志中可能按行出现，BEGIN/END 行不是敏感内容
+		for _, line := range strings.Split(s, "\n") {
+			if !strings.HasPrefix(line, "-----") {
+				RegisterSecret(line)
+			}
+		}
+	}
 }
@@ -0,0 +1,293 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// RedactedPlaceholder 替换敏感内容后的占位符
+const RedactedPlaceholder = "[REDACTED]"
+
+// minSecretLen 过短的值不登记为密钥，避免误伤普通日志内容。这类值只在敏感字段（如 password=）中被屏蔽，
+// 出现在消息或其他字段中时不会被屏蔽，AddSecret 会为此输出警告
+const minSecretLen = 6
+
+var (
+	urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+	sensitiveKeys = []string{
+		"password", "passwd", "secret", "private", "privkey", "mnemonic",
+		"token", "apikey", "api_key", "api-key", "credential",
+	}
+)
+
+// Redactor 从日志和错误信息中移除私钥、密码以及 RPC URL 中的 API key
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets map[string]struct{}
+	ordered []string // 按长度从长到短排列，避免较短的密钥（如去掉 0x 的私钥）先替换后留下残余
+}
+
+// NewRedactor 返回未登记任何密钥的 Redactor
+func NewRedactor() *Redactor {
+	return &Redactor{secrets: make(map[string]struct{})}
+}
+
+// AddSecret 登记需要在输出中屏蔽的明文值，十六进制私钥同时登记去掉 0x 前缀的形式。
+// 短于 minSecretLen 的值不登记并输出警告，警告中只包含长度
+func (r *Redactor) AddSecret(values ...string) {
+	var tooShort []int
+	// 在释放锁之后输出警告，日志经过脱敏 handler 时需要获取读锁
+	defer func() {
+		for _, n := range tooShort {
+			log.Warn("Secret too short to redact, it will only be hidden in sensitive log fields",
+				"length", n, "minLength", minSecretLen)
+		}
+	}()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLen {
+			if len(v) > 0 {
+				tooShort = append(tooShort, len(v))
+			}
+			continue
+		}
+		r.secrets[v] = struct{}{}
+		if trimmed := strings.TrimPrefix(v, "0x"); len(trimmed) >= minSecretLen {
+			r.secrets[trimmed] = struct{}{}
+		}
+	}
+
+	r.ordered = r.ordered[:0]
+	for secret := range r.secrets {
+		r.ordered = append(r.ordered, secret)
+	}
+	sort.Slice(r.ordered, func(i, j int) bool {
+		return len(r.ordered[i]) > len(r.ordered[j])
+	})
+}
+
+// Redact 屏蔽字符串中已登记的密钥，并清理其中 URL 携带的凭证
+func (r *Redactor) Redact(s string) string {
+	s = urlPattern.ReplaceAllStringFunc(s, RedactURL)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.ordered {
+		s = strings.ReplaceAll(s, secret, RedactedPlaceholder)
+	}
+	return s
+}
+
+// RedactError 返回错误信息已脱敏的错误，保留原错误供 errors.Is/As 判断
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// RedactURL 移除 URL 中的密码、敏感查询参数，以及路径中形如 API key 的片段
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), RedactedPlaceholder)
+		} else {
+			u.User = url.User(RedactedPlaceholder)
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if isSensitiveKey(key) {
+				query.Set(key, RedactedPlaceholder)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if looksLikeAPIKey(segment) {
+			segments[i] = RedactedPlaceholder
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	out, err := url.PathUnescape(u.String())
+	if err != nil {
+		return u.String()
+	}
+	return out
+}
+
+// looksLikeAPIKey 判断路径片段是否像 Infura/Alchemy 等服务商的 key
+func looksLikeAPIKey(segment string) bool {
+	if len(segment) < 16 {
+		return false
+	}
+	hasDigit := false
+	for _, c := range segment {
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "key" {
+		return true
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRedactingHandler 包装 slog.Handler，输出前对消息和所有字段脱敏
+func NewRedactingHandler(next slog.Handler, r *Redactor) slog.Handler {
+	return &redactingHandler{next: next, r: r}
+}
+
+type redactingHandler struct {
+	next slog.Handler
+	r    *Redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.r.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), r: h.r}
+}
+
+func (h *redactingHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	if isSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, RedactedPlaceholder)
+	}
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.r.Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, a := range group {
+			redacted[i] = h.redactAttr(a)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		var s string
+		switch v := value.Any().(type) {
+		case error:
+			s = v.Error()
+		case fmt.Stringer:
+			s = v.String()
+		default:
+			return attr
+		}
+		if redacted := h.r.Redact(s); redacted != s {
+			return slog.String(attr.Key, redacted)
+		}
+		return attr
+	default:
+		return attr
+	}
+}
+
+var (
+	defaultRedactor = NewRedactor()
+
+	// installMu 串行化检查和替换根 logger
+	installMu sync.Mutex
+)
+
+// RegisterSecret 向默认 Redactor 登记需要屏蔽的值，并确保根 logger 已经脱敏
+func RegisterSecret(values ...string) {
+	defaultRedactor.AddSecret(values...)
+	InstallLogRedaction()
+}
+
+// Redact 使用默认 Redactor 对字符串脱敏
+func Redact(s string) string {
+	return defaultRedactor.Redact(s)
+}
+
+// RedactError 使用默认 Redactor 对错误信息脱敏
+func RedactError(err error) error {
+	return defaultRedactor.RedactError(err)
+}
+
+// InstallLogRedaction 在当前根 logger 外包装脱敏 handler，已包装时不做处理。
+// 注意这会通过 log.SetDefault 替换进程全局的 go-ethereum 根 logger（同时替换 slog 的默认 logger），
+// 之前通过 log.Root() 取得的 logger 不受影响。RegisterSecret 会自动调用，因此加载私钥、密钥引用和
+// RPC URL 都会触发替换；启动时替换根 logger 后再调用一次即可重新包装。
+// 不希望修改全局 logger 时，使用 NewRedactingHandler 包装自己的 handler
+func InstallLogRedaction() {
+	installMu.Lock()
+	defer installMu.Unlock()
+
+	if h, ok := log.Root().Handler().(*redactingHandler); ok && h.r == defaultRedactor {
+		return
+	}
+	log.SetDefault(log.NewLogger(NewRedactingHandler(log.Root().Handler(), defaultRedactor)))
+}
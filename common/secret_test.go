@@ -0,0 +1,121 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSecretResolverEnv(t *testing.T) {
+	t.Setenv("VRF_TEST_SECRET", "env-secret-value")
+
+	r := common.NewSecretResolver()
+	secret, err := r.Resolve(context.Background(), "secret://env/VRF_TEST_SECRET")
+	require.Nil(t, err)
+	require.Equal(t, "env-secret-value", secret)
+
+	_, err = r.Resolve(context.Background(), "secret://env/VRF_TEST_SECRET_UNSET")
+	require.ErrorContains(t, err, "VRF_TEST_SECRET_UNSET")
+}
+
+func TestSecretResolverFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.Nil(t, os.WriteFile(path, []byte("file-secret-value\r\n"), 0o600))
+
+	r := common.NewSecretResolver()
+	secret, err := r.Resolve(context.Background(), "secret://file/"+path)
+	require.Nil(t, err)
+	require.Equal(t, "file-secret-value", secret)
+
+	_, err = r.Resolve(context.Background(), "secret://file/"+filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSecretResolverPlaintext(t *testing.T) {
+	t.Parallel()
+
+	r := common.NewSecretResolver()
+	// 不带 secret:// 前缀的值即使形如 scheme:key 也按明文返回
+	for _, ref := range []string{"plain", "env:HOME", "file:/etc/passwd", "https://rpc.example.com/"} {
+		secret, err := r.Resolve(context.Background(), ref)
+		require.Nil(t, err, ref)
+		require.Equal(t, ref, secret)
+	}
+}
+
+func TestSecretResolverUnknownScheme(t *testing.T) {
+	t.Parallel()
+
+	r := common.NewSecretResolver()
+	_, err := r.Resolve(context.Background(), "secret://vault/vrf/key")
+	require.ErrorIs(t, err, common.ErrUnknownSecretScheme)
+
+	_, err = r.Resolve(context.Background(), "secret://env")
+	require.NotNil(t, err)
+}
+
+func TestSecretResolverBackendError(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("permission denied")
+	r := common.NewSecretResolver()
+	r.Register("vault", func(ctx context.Context, key string) (string, error) {
+		require.Equal(t, "vrf/key", key)
+		return "", cause
+	})
+
+	secret, err := r.Resolve(context.Background(), "secret://vault/vrf/key")
+	require.ErrorIs(t, err, cause)
+	require.Empty(t, secret)
+}
+
+func TestParsePrivateKeyStrResolvesSecretRef(t *testing.T) {
+	key := "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	t.Setenv("VRF_TEST_PRIVATE_KEY", key)
+
+	parsed, err := common.ParsePrivateKeyStr("secret://env/VRF_TEST_PRIVATE_KEY")
+	require.Nil(t, err)
+	expected, err := common.ParsePrivateKeyStr(key)
+	require.Nil(t, err)
+	require.Equal(t, expected.D, parsed.D)
+
+	parsed, err = common.GetConfiguredPrivateKey("", "", "secret://env/VRF_TEST_PRIVATE_KEY", "")
+	require.Nil(t, err)
+	require.Equal(t, expected.D, parsed.D)
+
+	_, err = common.ParsePrivateKeyStr("secret://unregistered/key")
+	require.ErrorIs(t, err, common.ErrUnknownSecretScheme)
+}
+
+func ExampleRegisterSecretBackend() {
+	// 启动时在加载配置前注册外部密钥后端，实际使用时在这里调用 Vault 或云厂商的 SDK
+	vault := map[string]string{
+		"vrf/signer": "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318",
+	}
+	common.RegisterSecretBackend("vault", func(ctx context.Context, key string) (string, error) {
+		secret, ok := vault[key]
+		if !ok {
+			return "", errors.New("secret not found")
+		}
+		return secret, nil
+	})
+
+	// 配置中只写引用，私钥不会出现在配置文件和命令行参数中
+	key, err := common.ParsePrivateKeyStr("secret://vault/vrf/signer")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(crypto.PubkeyToAddress(key.PublicKey))
+	// Output: 0x2c7536E3605D9C16a7a3D7b1898e529396a65c23
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrSignerPolicy 交易不符合签名密钥的使用策略，拒绝签名
+var ErrSignerPolicy = errors.New("signer policy violation")
+
+// SignerPolicy 限制一个签名密钥可以签署的交易，字段为零值时不做对应限制
+type SignerPolicy struct {
+	AllowedTo             []common.Address // 允许的接收地址
+	AllowContractCreation bool             // 设置了 AllowedTo 时是否仍允许部署合约
+	AllowedSelectors      [][4]byte        // 允许调用的函数选择器，设置后 data 少于 4 字节的交易（如纯转账）被拒绝
+	MaxValue              *big.Int         // 单笔交易最多转出的金额
+	MaxGasFeeCap          *big.Int         // 每单位 gas 的最高价格，legacy 交易为 gasPrice
+	MaxFee                *big.Int         // 单笔交易最高手续费，即 gas 上限乘以 gasFeeCap
+	AllowSetCode          bool             // 是否允许签署 EIP-7702 交易，委托代码等同于交出账户控制权，默认拒绝
+}
+
+// Check 检查交易是否符合策略
+func (p *SignerPolicy) Check(tx *types.Transaction) error {
+	if tx.Type() == types.SetCodeTxType && !p.AllowSetCode {
+		return fmt.Errorf("%w: set code transactions not allowed", ErrSignerPolicy)
+	}
+
+	if len(p.AllowedTo) > 0 {
+		switch to := tx.To(); {
+		case to == nil:
+			if !p.AllowContractCreation {
+				return fmt.Errorf("%w: contract creation not allowed", ErrSignerPolicy)
+			}
+		case !containsAddress(p.AllowedTo, *to):
+			return fmt.Errorf("%w: recipient %s not allowed", ErrSignerPolicy, to)
+		}
+	}
+
+	if len(p.AllowedSelectors) > 0 {
+		data := tx.Data()
+		if len(data) < 4 || !containsSelector(p.AllowedSelectors, data[:4]) {
+			return fmt.Errorf("%w: function selector not allowed", ErrSignerPolicy)
+		}
+	}
+
+	if p.MaxValue != nil && tx.Value().Cmp(p.MaxValue) > 0 {
+		return fmt.Errorf("%w: value %s exceeds %s", ErrSignerPolicy, tx.Value(), p.MaxValue)
+	}
+	if p.MaxGasFeeCap != nil && tx.GasFeeCap().Cmp(p.MaxGasFeeCap) > 0 {
+		return fmt.Errorf("%w: gas fee cap %s exceeds %s", ErrSignerPolicy, tx.GasFeeCap(), p.MaxGasFeeCap)
+	}
+	if p.MaxFee != nil {
+		fee := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+		if fee.Cmp(p.MaxFee) > 0 {
+			return fmt.Errorf("%w: max fee %s exceeds %s", ErrSignerPolicy, fee, p.MaxFee)
+		}
+	}
+	return nil
+}
+
+// clone 深拷贝策略，签名函数持有的策略不受调用方之后修改的影响
+func (p *SignerPolicy) clone() SignerPolicy {
+	cloned := *p
+	cloned.AllowedTo = append([]common.Address(nil), p.AllowedTo...)
+	cloned.AllowedSelectors = append([][4]byte(nil), p.AllowedSelectors...)
+	cloned.MaxValue = cloneBig(p.MaxValue)
+	cloned.MaxGasFeeCap = cloneBig(p.MaxGasFeeCap)
+	cloned.MaxFee = cloneBig(p.MaxFee)
+	return cloned
+}
+
+func cloneBig(v *big.Int) *big.Int {
+	if v == nil {
+		return nil
+	}
+	return new(big.Int).Set(v)
+}
+
+// WithSignerPolicy 在签名前检查交易是否符合策略，上层代码出错也无法用该密钥签出策略之外的交易。
+// 策略在调用时被拷贝，之后修改传入的 policy 不影响返回的签名函数
+func WithSignerPolicy(signer bind.SignerFn, policy SignerPolicy) bind.SignerFn {
+	policy = policy.clone()
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if err := policy.Check(tx); err != nil {
+			return nil, err
+		}
+		return signer(address, tx)
+	}
+}
+
+// ApplySignerPolicy 为其他来源的 TransactOpts 的签名函数加上策略检查，
+// PrivateKeySignerFn 和 NewHSMTransactOpts 及其 WithPolicy 版本已经内置了策略检查
+func ApplySignerPolicy(opts *bind.TransactOpts, policy SignerPolicy) *bind.TransactOpts {
+	opts.Signer = WithSignerPolicy(opts.Signer, policy)
+	return opts
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSelector(selectors [][4]byte, selector []byte) bool {
+	for _, s := range selectors {
+		if bytes.Equal(s[:], selector) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package common_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	dcommon "github.com/DQYXACML/dapplink-vrf/common"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+var (
+	policyTo       = common.HexToAddress("0x01")
+	policySelector = [4]byte{0xaa, 0xbb, 0xcc, 0xdd}
+)
+
+func policyTx(to *common.Address, data []byte, value, gasFeeCap int64, gas uint64) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(gasFeeCap),
+		Gas:       gas,
+		To:        to,
+		Value:     big.NewInt(value),
+		Data:      data,
+	})
+}
+
+func TestSignerPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	other := common.HexToAddress("0x02")
+	call := append(policySelector[:], 0x01)
+	policy := dcommon.SignerPolicy{
+		AllowedTo:        []common.Address{policyTo},
+		AllowedSelectors: [][4]byte{policySelector},
+		MaxValue:         big.NewInt(10),
+		MaxGasFeeCap:     big.NewInt(100),
+		MaxFee:           big.NewInt(100 * 21000),
+	}
+
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		allowed bool
+	}{
+		{"allowed", policyTx(&policyTo, call, 10, 100, 21000), true},
+		{"recipient", policyTx(&other, call, 0, 100, 21000), false},
+		{"contract creation", policyTx(nil, call, 0, 100, 21000), false},
+		{"selector", policyTx(&policyTo, []byte{0x01, 0x02, 0x03, 0x04}, 0, 100, 21000), false},
+		{"plain transfer", policyTx(&policyTo, nil, 0, 100, 21000), false},
+		{"value", policyTx(&policyTo, call, 11, 100, 21000), false},
+		{"gas fee cap", policyTx(&policyTo, call, 0, 101, 21000), false},
+		{"max fee", policyTx(&policyTo, call, 0, 100, 21001), false},
+	}
+	for _, test := range tests {
+		err := policy.Check(test.tx)
+		if test.allowed {
+			require.Nil(t, err, test.name)
+		} else {
+			require.ErrorIs(t, err, dcommon.ErrSignerPolicy, test.name)
+		}
+	}
+
+	policy.AllowContractCreation = true
+	require.Nil(t, policy.Check(policyTx(nil, call, 0, 100, 21000)))
+}
+
+func TestSignerPolicyDeniesSetCodeByDefault(t *testing.T) {
+	t.Parallel()
+
+	tx := types.NewTx(&types.SetCodeTx{
+		ChainID:   uint256.NewInt(1),
+		GasTipCap: uint256.NewInt(1),
+		GasFeeCap: uint256.NewInt(100),
+		Gas:       50000,
+		To:        policyTo,
+		Value:     new(uint256.Int),
+		AuthList:  []types.SetCodeAuthorization{{Address: common.HexToAddress("0x02")}},
+	})
+
+	var policy dcommon.SignerPolicy
+	require.ErrorIs(t, policy.Check(tx), dcommon.ErrSignerPolicy)
+
+	policy.AllowSetCode = true
+	require.Nil(t, policy.Check(tx))
+}
+
+func TestPrivateKeySignerFnEnforcesPolicy(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := dcommon.PrivateKeySignerFnWithPolicy(key, big.NewInt(1), dcommon.SignerPolicy{
+		AllowedTo: []common.Address{policyTo},
+	})
+
+	signed, err := signer(from, policyTx(&policyTo, nil, 0, 100, 21000))
+	require.Nil(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+	require.Nil(t, err)
+	require.Equal(t, from, sender)
+
+	other := common.HexToAddress("0x02")
+	_, err = signer(from, policyTx(&other, nil, 0, 100, 21000))
+	require.ErrorIs(t, err, dcommon.ErrSignerPolicy)
+}
+
+func TestSignerPolicyCopiedAtConstruction(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	allowed := []common.Address{policyTo}
+	maxValue := big.NewInt(10)
+	signer := dcommon.PrivateKeySignerFnWithPolicy(key, big.NewInt(1), dcommon.SignerPolicy{
+		AllowedTo: allowed,
+		MaxValue:  maxValue,
+	})
+
+	// 修改调用方持有的切片和数值不应放宽已创建签名函数的策略
+	other := common.HexToAddress("0x02")
+	allowed[0] = other
+	maxValue.SetInt64(1000)
+
+	_, err = signer(from, policyTx(&other, nil, 0, 100, 21000))
+	require.ErrorIs(t, err, dcommon.ErrSignerPolicy)
+	_, err = signer(from, policyTx(&policyTo, nil, 100, 100, 21000))
+	require.ErrorIs(t, err, dcommon.ErrSignerPolicy)
+	_, err = signer(from, policyTx(&policyTo, nil, 10, 100, 21000))
+	require.Nil(t, err)
+}
+
+func TestPrivateKeySignerFnDeniesSetCode(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := dcommon.PrivateKeySignerFn(key, big.NewInt(1))
+	_, err = signer(from, policyTx(&policyTo, nil, 0, 100, 21000))
+	require.Nil(t, err)
+
+	tx := types.NewTx(&types.SetCodeTx{
+		ChainID:   uint256.NewInt(1),
+		GasTipCap: uint256.NewInt(1),
+		GasFeeCap: uint256.NewInt(100),
+		Gas:       50000,
+		To:        policyTo,
+		Value:     new(uint256.Int),
+		AuthList:  []types.SetCodeAuthorization{{}},
+	})
+	_, err = signer(from, tx)
+	require.ErrorIs(t, err, dcommon.ErrSignerPolicy)
+}
@@ -0,0 +1,73 @@
+package ethereumcli
+
+import (
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// DefaultMaxReceiptBatchSize 每次 RPC 批量查询的默认最大回执数，与 geth 默认的 batch 上限（1000）
+// 和常见服务商的限制相比留有余量
+const DefaultMaxReceiptBatchSize = 100
+
+// BatchReceiptClient 在 ethclient 的基础上支持一次 RPC 批量查询交易回执，可作为 txmgr 的 backend
+type BatchReceiptClient struct {
+	*ethclient.Client
+
+	MaxBatchSize int // 每次 RPC 批量查询的最大回执数，超过时分多次查询，<= 0 时使用 DefaultMaxReceiptBatchSize
+}
+
+func NewBatchReceiptClient(client *ethclient.Client) *BatchReceiptClient {
+	return &BatchReceiptClient{Client: client, MaxBatchSize: DefaultMaxReceiptBatchSize}
+}
+
+// TransactionReceipts 批量查询交易回执，结果与 txHashes 一一对应。
+// 与 ethclient.TransactionReceipt 一致，交易未打包时回执为 nil、错误为 ethereum.NotFound；
+// 某一批查询失败时只有该批的交易返回错误
+func (c *BatchReceiptClient) TransactionReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, []error) {
+	receipts := make([]*types.Receipt, len(txHashes))
+	errs := make([]error, len(txHashes))
+
+	batchSize := c.MaxBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultMaxReceiptBatchSize
+	}
+	for start := 0; start < len(txHashes); start += batchSize {
+		end := min(start+batchSize, len(txHashes))
+		c.fetchBatch(ctx, txHashes[start:end], receipts[start:end], errs[start:end])
+	}
+	return receipts, errs
+}
+
+func (c *BatchReceiptClient) fetchBatch(ctx context.Context, txHashes []common.Hash, receipts []*types.Receipt, errs []error) {
+	elems := make([]rpc.BatchElem, len(txHashes))
+	for i, txHash := range txHashes {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{txHash},
+			Result: &receipts[i],
+		}
+	}
+
+	if err := c.Client.Client().BatchCallContext(ctx, elems); err != nil {
+		for i := range errs {
+			receipts[i] = nil
+			errs[i] = err
+		}
+		return
+	}
+
+	for i := range elems {
+		switch {
+		case elems[i].Error != nil:
+			receipts[i] = nil
+			errs[i] = elems[i].Error
+		case receipts[i] == nil:
+			// 节点返回 null，交易尚未打包
+			errs[i] = ethereum.NotFound
+		}
+	}
+}
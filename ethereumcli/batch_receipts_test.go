@@ -0,0 +1,102 @@
+package ethereumcli_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/ethereumcli"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errReceiptBackend = errors.New("receipt backend failure")
+
+// receiptService 实现 eth_getTransactionReceipt，mined 中的交易返回回执，failing 中的交易返回错误，其他返回 null
+type receiptService struct {
+	mined   map[common.Hash]bool
+	failing map[common.Hash]bool
+}
+
+func (s *receiptService) GetTransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	if s.failing[txHash] {
+		return nil, errReceiptBackend
+	}
+	if !s.mined[txHash] {
+		return nil, nil
+	}
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      txHash,
+		BlockNumber: big.NewInt(1),
+		Logs:        []*types.Log{},
+	}, nil
+}
+
+// newReceiptClient 启动进程内的 rpc.Server，batchLimit 为服务端单次 batch 的最大请求数
+func newReceiptClient(t *testing.T, service *receiptService, batchLimit int) *ethereumcli.BatchReceiptClient {
+	server := rpc.NewServer()
+	server.SetBatchLimits(batchLimit, 1<<20)
+	require.Nil(t, server.RegisterName("eth", service))
+	t.Cleanup(server.Stop)
+
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+	return ethereumcli.NewBatchReceiptClient(ethclient.NewClient(client))
+}
+
+func TestBatchReceiptClientChunksBatches(t *testing.T) {
+	t.Parallel()
+
+	txHashes := make([]common.Hash, 5)
+	service := &receiptService{mined: make(map[common.Hash]bool)}
+	for i := range txHashes {
+		txHashes[i] = common.BigToHash(big.NewInt(int64(i + 1)))
+		service.mined[txHashes[i]] = true
+	}
+
+	// 服务端每个 batch 最多 2 个请求，不分批时整批失败
+	client := newReceiptClient(t, service, 2)
+	client.MaxBatchSize = 5
+	_, errs := client.TransactionReceipts(context.Background(), txHashes)
+	for _, err := range errs {
+		require.NotNil(t, err)
+	}
+
+	client.MaxBatchSize = 2
+	receipts, errs := client.TransactionReceipts(context.Background(), txHashes)
+	for i, txHash := range txHashes {
+		require.Nil(t, errs[i])
+		require.Equal(t, txHash, receipts[i].TxHash)
+	}
+}
+
+func TestBatchReceiptClientElementResults(t *testing.T) {
+	t.Parallel()
+
+	mined := common.HexToHash("0x01")
+	pending := common.HexToHash("0x02")
+	failing := common.HexToHash("0x03")
+	service := &receiptService{
+		mined:   map[common.Hash]bool{mined: true},
+		failing: map[common.Hash]bool{failing: true},
+	}
+	client := newReceiptClient(t, service, 100)
+
+	receipts, errs := client.TransactionReceipts(context.Background(), []common.Hash{mined, pending, failing})
+	require.Nil(t, errs[0])
+	require.Equal(t, mined, receipts[0].TxHash)
+
+	// null 结果与 ethclient.TransactionReceipt 一致地映射为 NotFound
+	require.Nil(t, receipts[1])
+	require.ErrorIs(t, errs[1], ethereum.NotFound)
+
+	require.Nil(t, receipts[2])
+	require.ErrorContains(t, errs[2], errReceiptBackend.Error())
+}
@@ -1,14 +1,28 @@
 package ethereumcli
 
 import (
+	"github.com/DQYXACML/dapplink-vrf/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"golang.org/x/net/context"
 	"time"
 )
 
+// EthClientWithTimeout 连接节点，url 可以是 secret://<scheme>/<key> 形式的密钥引用，
+// 避免在配置中写入带 API key 的 RPC URL
 func EthClientWithTimeout(ctx context.Context, url string) (*ethclient.Client, error) {
+	common.InstallLogRedaction()
+
+	url, err := common.ResolveSecret(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
 	ctxt, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	return ethclient.DialContext(ctxt, url)
+	client, err := ethclient.DialContext(ctxt, url)
+	if err != nil {
+		return nil, common.RedactError(err)
+	}
+	return client, nil
 }
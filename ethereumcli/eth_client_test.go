@@ -0,0 +1,25 @@
+package ethereumcli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/common"
+	"github.com/DQYXACML/dapplink-vrf/ethereumcli"
+)
+
+func TestEthClientWithTimeoutResolvesSecretRef(t *testing.T) {
+	// HTTP 连接在首次请求时才建立，这里只验证引用被解析为可用的 URL
+	t.Setenv("VRF_TEST_RPC_URL", "http://127.0.0.1:8545/v3/9aa3d95b3bc440fa88ea12eaa4456161")
+
+	client, err := ethereumcli.EthClientWithTimeout(context.Background(), "secret://env/VRF_TEST_RPC_URL")
+	require.Nil(t, err)
+	client.Close()
+
+	_, err = ethereumcli.EthClientWithTimeout(context.Background(), "secret://env/VRF_TEST_RPC_URL_UNSET")
+	require.NotNil(t, err)
+	_, err = ethereumcli.EthClientWithTimeout(context.Background(), "secret://unregistered/rpc")
+	require.ErrorIs(t, err, common.ErrUnknownSecretScheme)
+}
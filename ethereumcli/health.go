@@ -0,0 +1,121 @@
+package ethereumcli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNodeSyncing 节点仍在同步，状态可能落后于链
+	ErrNodeSyncing = errors.New("ethereumcli: node is syncing")
+	// ErrNodeIsolated 节点的 peer 数量不足，可能停留在孤立的分叉上
+	ErrNodeIsolated = errors.New("ethereumcli: node has too few peers")
+)
+
+const (
+	// methodNotFoundCode JSON-RPC 方法不存在的错误码
+	methodNotFoundCode = -32601
+	// peerCountUnavailableMsg geth 未开放 net 命名空间时的错误信息，经过不保留错误码的代理时按该信息判断
+	peerCountUnavailableMsg = "the method net_peerCount does not exist/is not available"
+)
+
+// HealthReader 查询节点同步状态和 peer 数量，*ethclient.Client 实现了该接口
+type HealthReader interface {
+	SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error)
+	PeerCount(ctx context.Context) (uint64, error)
+}
+
+var _ HealthReader = (*ethclient.Client)(nil)
+
+// CheckNodeHealth 在依赖节点状态前检查 eth_syncing 和 net_peerCount，
+// 同步中或 peer 少于 minPeers 的节点视为不健康；服务商未开放 net_peerCount 时跳过 peer 检查
+func CheckNodeHealth(ctx context.Context, client HealthReader, minPeers uint64) error {
+	progress, err := client.SyncProgress(ctx)
+	if err != nil {
+		return fmt.Errorf("query sync progress: %w", err)
+	}
+	if progress != nil && !progress.Done() {
+		return fmt.Errorf("%w: current block %d, highest block %d",
+			ErrNodeSyncing, progress.CurrentBlock, progress.HighestBlock)
+	}
+
+	if minPeers == 0 {
+		return nil
+	}
+	peers, err := client.PeerCount(ctx)
+	if isMethodUnavailable(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("query peer count: %w", err)
+	}
+	if peers < minPeers {
+		return fmt.Errorf("%w: %d peers, want at least %d", ErrNodeIsolated, peers, minPeers)
+	}
+	return nil
+}
+
+// EthClientWithHealthCheck 连接节点并检查其健康状态，不健康时关闭连接并返回错误
+func EthClientWithHealthCheck(ctx context.Context, url string, minPeers uint64) (*ethclient.Client, error) {
+	client, err := EthClientWithTimeout(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckNodeHealth(ctx, client, minPeers); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// HealthMonitor 缓存最近一次 CheckNodeHealth 的结果。在每次依赖节点状态做决策（如履约）前调用 Check，
+// 结果超过 maxAge 时重新检查，避免只在连接时检查一次
+type HealthMonitor struct {
+	client   HealthReader
+	minPeers uint64
+	maxAge   time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+func NewHealthMonitor(client HealthReader, minPeers uint64, maxAge time.Duration) *HealthMonitor {
+	return &HealthMonitor{client: client, minPeers: minPeers, maxAge: maxAge}
+}
+
+// Check 返回节点是否健康，缓存结果超过 maxAge 时重新查询
+func (m *HealthMonitor) Check(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.checkedAt.IsZero() && time.Since(m.checkedAt) < m.maxAge {
+		return m.err
+	}
+	err := CheckNodeHealth(ctx, m.client, m.minPeers)
+	if ctx.Err() != nil {
+		// 调用方取消导致的失败不代表节点状态，不缓存
+		return err
+	}
+	m.err = err
+	m.checkedAt = time.Now()
+	return err
+}
+
+// isMethodUnavailable 节点是否不支持查询的方法，只认 -32601 错误码和 geth 的原始错误信息
+func isMethodUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+		return true
+	}
+	return err.Error() == peerCountUnavailableMsg
+}
@@ -0,0 +1,85 @@
+package ethereumcli_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/ethereumcli"
+	"github.com/ethereum/go-ethereum"
+)
+
+// rpcError 带 JSON-RPC 错误码的错误
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e *rpcError) Error() string  { return e.msg }
+func (e *rpcError) ErrorCode() int { return e.code }
+
+// fakeHealthReader 返回固定的同步状态和 peer 数量
+type fakeHealthReader struct {
+	progress *ethereum.SyncProgress
+	peers    uint64
+	peersErr error
+	calls    int
+}
+
+func (r *fakeHealthReader) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	r.calls++
+	return r.progress, nil
+}
+
+func (r *fakeHealthReader) PeerCount(ctx context.Context) (uint64, error) {
+	return r.peers, r.peersErr
+}
+
+func TestCheckNodeHealth(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	require.Nil(t, ethereumcli.CheckNodeHealth(ctx, &fakeHealthReader{peers: 3}, 3))
+
+	syncing := &fakeHealthReader{progress: &ethereum.SyncProgress{CurrentBlock: 1, HighestBlock: 10}, peers: 3}
+	require.ErrorIs(t, ethereumcli.CheckNodeHealth(ctx, syncing, 3), ethereumcli.ErrNodeSyncing)
+
+	isolated := &fakeHealthReader{peers: 1}
+	require.ErrorIs(t, ethereumcli.CheckNodeHealth(ctx, isolated, 3), ethereumcli.ErrNodeIsolated)
+	require.Nil(t, ethereumcli.CheckNodeHealth(ctx, isolated, 0))
+}
+
+func TestCheckNodeHealthPeerCountUnavailable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, err := range []error{
+		&rpcError{code: -32601, msg: "method not found"},
+		errors.New("the method net_peerCount does not exist/is not available"),
+	} {
+		require.Nil(t, ethereumcli.CheckNodeHealth(ctx, &fakeHealthReader{peersErr: err}, 3), err.Error())
+	}
+
+	// 其他错误不能被当作方法不存在而跳过 peer 检查
+	unrelated := errors.New("upstream not available")
+	require.ErrorIs(t, ethereumcli.CheckNodeHealth(ctx, &fakeHealthReader{peersErr: unrelated}, 3), unrelated)
+}
+
+func TestHealthMonitorRechecks(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeHealthReader{peers: 3}
+	monitor := ethereumcli.NewHealthMonitor(client, 3, 50*time.Millisecond)
+
+	require.Nil(t, monitor.Check(context.Background()))
+	client.peers = 1
+	require.Nil(t, monitor.Check(context.Background()))
+	require.Equal(t, 1, client.calls)
+
+	time.Sleep(60 * time.Millisecond)
+	require.ErrorIs(t, monitor.Check(context.Background()), ethereumcli.ErrNodeIsolated)
+	require.Equal(t, 2, client.calls)
+}
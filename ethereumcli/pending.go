@@ -0,0 +1,112 @@
+package ethereumcli
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+	"math/big"
+)
+
+// PendingStateReader 查询 latest 和 pending 两种状态，*ethclient.Client 实现了该接口
+type PendingStateReader interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+var _ PendingStateReader = (*ethclient.Client)(nil)
+
+// invalidParamsCode JSON-RPC 参数错误的错误码，部分服务商不支持 pending 块标签时返回
+const invalidParamsCode = -32602
+
+// AccountState 账户在 latest 和 pending 状态下的 nonce 与余额
+type AccountState struct {
+	LatestNonce        uint64
+	PendingNonce       uint64
+	LatestBalance      *big.Int
+	PendingBalance     *big.Int
+	PendingUnavailable bool // 节点不支持 pending 状态，PendingNonce 和 PendingBalance 取自 latest
+}
+
+// UnminedCount 已发送但尚未打包的交易数量
+func (s *AccountState) UnminedCount() uint64 {
+	if s.PendingNonce < s.LatestNonce {
+		return 0
+	}
+	return s.PendingNonce - s.LatestNonce
+}
+
+// PendingSpend pending 交易预计花费的余额
+func (s *AccountState) PendingSpend() *big.Int {
+	spend := new(big.Int).Sub(s.LatestBalance, s.PendingBalance)
+	if spend.Sign() < 0 {
+		return new(big.Int)
+	}
+	return spend
+}
+
+// GetAccountState 查询账户的 latest 和 pending 状态，使 nonce 管理和余额监控能计入自己未打包的交易。
+// 节点不支持 pending 状态时退回 latest 并设置 PendingUnavailable，其他错误直接返回
+func GetAccountState(ctx context.Context, client PendingStateReader, account common.Address) (*AccountState, error) {
+	latestNonce, err := client.NonceAt(ctx, account, nil)
+	if err != nil {
+		return nil, err
+	}
+	latestBalance, err := client.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return nil, err
+	}
+	state := &AccountState{
+		LatestNonce:    latestNonce,
+		PendingNonce:   latestNonce,
+		LatestBalance:  latestBalance,
+		PendingBalance: latestBalance,
+	}
+
+	pendingNonce, err := client.PendingNonceAt(ctx, account)
+	if isPendingUnavailable(err) {
+		state.PendingUnavailable = true
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pendingBalance, err := client.PendingBalanceAt(ctx, account)
+	if isPendingUnavailable(err) {
+		state.PendingUnavailable = true
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.PendingNonce = pendingNonce
+	state.PendingBalance = pendingBalance
+	return state, nil
+}
+
+// PendingHeader 查询 pending 块的区块头，节点不支持 pending 块时返回最新区块头
+func PendingHeader(ctx context.Context, client PendingStateReader) (*types.Header, error) {
+	header, err := client.HeaderByNumber(ctx, big.NewInt(int64(rpc.PendingBlockNumber)))
+	if isPendingUnavailable(err) {
+		return client.HeaderByNumber(ctx, nil)
+	}
+	return header, err
+}
+
+// isPendingUnavailable 节点不支持 pending 块标签：方法不存在、参数错误或找不到 pending 块
+func isPendingUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isMethodUnavailable(err) || errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == invalidParamsCode
+}
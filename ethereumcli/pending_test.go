@@ -0,0 +1,127 @@
+package ethereumcli_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/ethereumcli"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakePendingReader 返回固定的 latest 和 pending 状态，pendingErr 非空时 pending 查询返回该错误
+type fakePendingReader struct {
+	latestNonce    uint64
+	pendingNonce   uint64
+	latestBalance  *big.Int
+	pendingBalance *big.Int
+	latestErr      error
+	pendingErr     error
+	headers        []*big.Int // HeaderByNumber 收到的块高
+}
+
+func (r *fakePendingReader) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return r.latestNonce, r.latestErr
+}
+
+func (r *fakePendingReader) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return r.pendingNonce, r.pendingErr
+}
+
+func (r *fakePendingReader) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return r.latestBalance, r.latestErr
+}
+
+func (r *fakePendingReader) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return r.pendingBalance, r.pendingErr
+}
+
+func (r *fakePendingReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	r.headers = append(r.headers, number)
+	if number != nil && number.Int64() == int64(rpc.PendingBlockNumber) {
+		if r.pendingErr != nil {
+			return nil, r.pendingErr
+		}
+		return &types.Header{Number: big.NewInt(11)}, nil
+	}
+	return &types.Header{Number: big.NewInt(10)}, nil
+}
+
+func TestGetAccountStatePending(t *testing.T) {
+	t.Parallel()
+
+	client := &fakePendingReader{
+		latestNonce:    3,
+		pendingNonce:   5,
+		latestBalance:  big.NewInt(100),
+		pendingBalance: big.NewInt(70),
+	}
+	state, err := ethereumcli.GetAccountState(context.Background(), client, common.HexToAddress("0x01"))
+	require.Nil(t, err)
+	require.False(t, state.PendingUnavailable)
+	require.Equal(t, uint64(5), state.PendingNonce)
+	require.Equal(t, big.NewInt(70), state.PendingBalance)
+	require.Equal(t, uint64(2), state.UnminedCount())
+	require.Equal(t, big.NewInt(30), state.PendingSpend())
+
+	header, err := ethereumcli.PendingHeader(context.Background(), client)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(11), header.Number)
+}
+
+func TestGetAccountStateFallsBackToLatest(t *testing.T) {
+	t.Parallel()
+
+	for _, pendingErr := range []error{
+		&rpcError{code: -32601, msg: "method not found"},
+		&rpcError{code: -32602, msg: "pending block tag not supported"},
+		ethereum.NotFound,
+	} {
+		client := &fakePendingReader{
+			latestNonce:    3,
+			pendingNonce:   5,
+			latestBalance:  big.NewInt(100),
+			pendingBalance: big.NewInt(70),
+			pendingErr:     pendingErr,
+		}
+		state, err := ethereumcli.GetAccountState(context.Background(), client, common.HexToAddress("0x01"))
+		require.Nil(t, err, pendingErr.Error())
+		require.True(t, state.PendingUnavailable)
+		require.Equal(t, uint64(3), state.PendingNonce)
+		require.Equal(t, big.NewInt(100), state.PendingBalance)
+		require.Equal(t, uint64(0), state.UnminedCount())
+		require.Zero(t, state.PendingSpend().Sign())
+
+		header, err := ethereumcli.PendingHeader(context.Background(), client)
+		require.Nil(t, err)
+		require.Equal(t, big.NewInt(10), header.Number)
+		require.Nil(t, client.headers[len(client.headers)-1])
+	}
+}
+
+func TestGetAccountStateErrors(t *testing.T) {
+	t.Parallel()
+
+	// latest 查询失败直接返回
+	latestErr := errors.New("connection refused")
+	client := &fakePendingReader{latestErr: latestErr}
+	_, err := ethereumcli.GetAccountState(context.Background(), client, common.HexToAddress("0x01"))
+	require.ErrorIs(t, err, latestErr)
+
+	// 其他 pending 错误不能被当作不支持 pending 而退回 latest
+	pendingErr := errors.New("upstream timeout")
+	client = &fakePendingReader{
+		latestBalance: big.NewInt(100),
+		pendingErr:    pendingErr,
+	}
+	_, err = ethereumcli.GetAccountState(context.Background(), client, common.HexToAddress("0x01"))
+	require.ErrorIs(t, err, pendingErr)
+	_, err = ethereumcli.PendingHeader(context.Background(), client)
+	require.ErrorIs(t, err, pendingErr)
+}
@@ -0,0 +1,160 @@
+package ethereumcli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"golang.org/x/net/context"
+)
+
+var (
+	// ErrReceiptsRootMismatch 节点返回的回执与区块头中的 receiptsRoot 不一致
+	ErrReceiptsRootMismatch = errors.New("ethereumcli: receipts do not match block receipts root")
+	// ErrInvalidReceiptProof 回执证明校验失败
+	ErrInvalidReceiptProof = errors.New("ethereumcli: invalid receipt proof")
+	// ErrReceiptNotInBlock 节点返回的区块回执中找不到该交易的回执，或与单独查询的回执不一致
+	ErrReceiptNotInBlock = errors.New("ethereumcli: receipt not found in block receipts")
+)
+
+// ReceiptProofReader 构建回执证明需要的查询，*ethclient.Client 实现了该接口
+type ReceiptProofReader interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+}
+
+var _ ReceiptProofReader = (*ethclient.Client)(nil)
+
+// ReceiptProof 交易回执在区块 receiptsRoot 下的 Merkle Patricia 证明，
+// 其他链或轻客户端持有可信的区块头后即可验证交易已执行
+type ReceiptProof struct {
+	BlockHash    common.Hash
+	BlockNumber  uint64
+	ReceiptsRoot common.Hash
+	TxIndex      uint
+	Receipt      []byte   // 共识编码的回执，即 trie 中的值
+	Proof        [][]byte // 从根到叶子路径上的 trie 节点
+}
+
+// Key 回执在 trie 中的 key，即 RLP 编码的交易索引
+func (p *ReceiptProof) Key() []byte {
+	return rlp.AppendUint64(nil, uint64(p.TxIndex))
+}
+
+// BuildReceiptProof 查询交易所在区块的全部回执，重建回执 trie 并生成该交易回执的证明。
+// 节点返回的数据不完整或前后不一致时返回错误
+func BuildReceiptProof(ctx context.Context, client ReceiptProofReader, txHash common.Hash) (*ReceiptProof, error) {
+	receipt, err := client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("receipt %s: %w", txHash, ethereum.NotFound)
+	}
+	header, err := client.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil || header.Number == nil {
+		return nil, fmt.Errorf("header %s: %w", receipt.BlockHash, ethereum.NotFound)
+	}
+	receipts, err := client.BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(receipt.BlockHash, true))
+	if err != nil {
+		return nil, err
+	}
+	if receipt.TransactionIndex >= uint(len(receipts)) {
+		return nil, fmt.Errorf("%w: index %d, block has %d receipts",
+			ErrReceiptNotInBlock, receipt.TransactionIndex, len(receipts))
+	}
+	if got := receipts[receipt.TransactionIndex]; got == nil || got.TxHash != txHash {
+		return nil, fmt.Errorf("%w: index %d does not hold %s", ErrReceiptNotInBlock, receipt.TransactionIndex, txHash)
+	}
+	for i, r := range receipts {
+		if r == nil {
+			return nil, fmt.Errorf("%w: missing receipt at index %d", ErrReceiptNotInBlock, i)
+		}
+	}
+
+	tr := trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	list := types.Receipts(receipts)
+	var value bytes.Buffer
+	for i := range list {
+		value.Reset()
+		list.EncodeIndex(i, &value)
+		if err := tr.Update(rlp.AppendUint64(nil, uint64(i)), common.CopyBytes(value.Bytes())); err != nil {
+			return nil, err
+		}
+	}
+	if root := tr.Hash(); root != header.ReceiptHash {
+		return nil, fmt.Errorf("%w: computed %s, header %s", ErrReceiptsRootMismatch, root, header.ReceiptHash)
+	}
+
+	proof := &ReceiptProof{
+		BlockHash:    receipt.BlockHash,
+		BlockNumber:  header.Number.Uint64(),
+		ReceiptsRoot: header.ReceiptHash,
+		TxIndex:      receipt.TransactionIndex,
+	}
+	nodes := &proofList{}
+	if err := tr.Prove(proof.Key(), nodes); err != nil {
+		return nil, err
+	}
+	proof.Proof = nodes.nodes
+	proof.Receipt, err = tr.Get(proof.Key())
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// VerifyReceiptProof 校验证明与 receiptsRoot 一致，返回解码后的回执。
+// 调用方需要自行确认 ReceiptsRoot 来自可信的区块头
+func VerifyReceiptProof(proof *ReceiptProof) (*types.Receipt, error) {
+	db := memorydb.New()
+	for _, node := range proof.Proof {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	value, err := trie.VerifyProof(proof.ReceiptsRoot, proof.Key(), db)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidReceiptProof, err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("%w: receipt not in trie", ErrInvalidReceiptProof)
+	}
+	if !bytes.Equal(value, proof.Receipt) {
+		return nil, fmt.Errorf("%w: receipt does not match proven value", ErrInvalidReceiptProof)
+	}
+
+	receipt := new(types.Receipt)
+	if err := receipt.UnmarshalBinary(value); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// proofList 按写入顺序收集证明节点
+type proofList struct {
+	nodes [][]byte
+}
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	l.nodes = append(l.nodes, common.CopyBytes(value))
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	return errors.New("ethereumcli: delete not supported on proof list")
+}
@@ -0,0 +1,205 @@
+package ethereumcli_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/ethereumcli"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// fakeProofReader 单个区块的回执，header 的 receiptsRoot 由回执计算得到
+type fakeProofReader struct {
+	header   *types.Header
+	receipts []*types.Receipt
+}
+
+func newFakeProofReader(n int) *fakeProofReader {
+	receipts := make([]*types.Receipt, n)
+	for i := range receipts {
+		receipts[i] = &types.Receipt{
+			Type:              types.DynamicFeeTxType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			Logs: []*types.Log{{
+				Address: common.BigToAddress(big.NewInt(int64(i))),
+				Topics:  []common.Hash{common.BigToHash(big.NewInt(int64(i)))},
+				Data:    []byte{byte(i)},
+			}},
+			TxHash: common.BigToHash(big.NewInt(int64(i + 1))),
+		}
+		receipts[i].Bloom = types.CreateBloom(receipts[i])
+	}
+	header := &types.Header{
+		Number:      big.NewInt(100),
+		ReceiptHash: types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil)),
+	}
+	for i, receipt := range receipts {
+		receipt.BlockHash = header.Hash()
+		receipt.BlockNumber = header.Number
+		receipt.TransactionIndex = uint(i)
+	}
+	return &fakeProofReader{header: header, receipts: receipts}
+}
+
+func (r *fakeProofReader) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	for _, receipt := range r.receipts {
+		if receipt.TxHash == txHash {
+			return receipt, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (r *fakeProofReader) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return r.header, nil
+}
+
+func (r *fakeProofReader) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	return r.receipts, nil
+}
+
+func TestReceiptProofRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// 超过 128 笔时交易索引 0x80 之后的 key 编码为多字节
+	reader := newFakeProofReader(200)
+	for _, index := range []int{0, 1, 127, 128, 199} {
+		want := reader.receipts[index]
+		proof, err := ethereumcli.BuildReceiptProof(context.Background(), reader, want.TxHash)
+		require.Nil(t, err)
+		require.Equal(t, reader.header.ReceiptHash, proof.ReceiptsRoot)
+		require.Equal(t, uint(index), proof.TxIndex)
+
+		receipt, err := ethereumcli.VerifyReceiptProof(proof)
+		require.Nil(t, err)
+		require.Equal(t, want.CumulativeGasUsed, receipt.CumulativeGasUsed)
+		require.Equal(t, want.Logs[0].Data, receipt.Logs[0].Data)
+	}
+}
+
+func TestVerifyReceiptProofRejectsTampering(t *testing.T) {
+	t.Parallel()
+
+	reader := newFakeProofReader(3)
+	proof, err := ethereumcli.BuildReceiptProof(context.Background(), reader, reader.receipts[1].TxHash)
+	require.Nil(t, err)
+
+	tampered := *proof
+	tampered.Receipt = common.CopyBytes(proof.Receipt)
+	tampered.Receipt[len(tampered.Receipt)-1] ^= 0xff
+	_, err = ethereumcli.VerifyReceiptProof(&tampered)
+	require.ErrorIs(t, err, ethereumcli.ErrInvalidReceiptProof)
+
+	tampered = *proof
+	tampered.TxIndex = 2
+	_, err = ethereumcli.VerifyReceiptProof(&tampered)
+	require.ErrorIs(t, err, ethereumcli.ErrInvalidReceiptProof)
+
+	tampered = *proof
+	tampered.ReceiptsRoot = common.HexToHash("0x01")
+	_, err = ethereumcli.VerifyReceiptProof(&tampered)
+	require.ErrorIs(t, err, ethereumcli.ErrInvalidReceiptProof)
+}
+
+func TestBuildReceiptProofRejectsRootMismatch(t *testing.T) {
+	t.Parallel()
+
+	reader := newFakeProofReader(3)
+	reader.receipts[2].CumulativeGasUsed++
+	_, err := ethereumcli.BuildReceiptProof(context.Background(), reader, reader.receipts[0].TxHash)
+	require.ErrorIs(t, err, ethereumcli.ErrReceiptsRootMismatch)
+}
+
+// malformedProofReader 返回不完整或不一致的数据
+type malformedProofReader struct {
+	receipt  *types.Receipt
+	header   *types.Header
+	receipts []*types.Receipt
+}
+
+func (r *malformedProofReader) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return r.receipt, nil
+}
+
+func (r *malformedProofReader) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return r.header, nil
+}
+
+func (r *malformedProofReader) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	return r.receipts, nil
+}
+
+func TestBuildReceiptProofRejectsMalformedResponses(t *testing.T) {
+	t.Parallel()
+
+	base := newFakeProofReader(3)
+	outOfRange := *base.receipts[1]
+	outOfRange.TransactionIndex = 3
+
+	tests := []struct {
+		name     string
+		receipt  *types.Receipt
+		header   *types.Header
+		receipts []*types.Receipt
+		err      error
+	}{
+		{
+			name:     "nil receipt",
+			header:   base.header,
+			receipts: base.receipts,
+			err:      ethereum.NotFound,
+		},
+		{
+			name:     "nil header",
+			receipt:  base.receipts[1],
+			receipts: base.receipts,
+			err:      ethereum.NotFound,
+		},
+		{
+			name:     "index out of range",
+			receipt:  &outOfRange,
+			header:   base.header,
+			receipts: base.receipts,
+			err:      ethereumcli.ErrReceiptNotInBlock,
+		},
+		{
+			name:     "index holds another tx",
+			receipt:  base.receipts[1],
+			header:   base.header,
+			receipts: []*types.Receipt{base.receipts[0], base.receipts[2], base.receipts[1]},
+			err:      ethereumcli.ErrReceiptNotInBlock,
+		},
+		{
+			name:     "nil block receipt",
+			receipt:  base.receipts[1],
+			header:   base.header,
+			receipts: []*types.Receipt{base.receipts[0], base.receipts[1], nil},
+			err:      ethereumcli.ErrReceiptNotInBlock,
+		},
+		{
+			name:    "empty block receipts",
+			receipt: base.receipts[1],
+			header:  base.header,
+			err:     ethereumcli.ErrReceiptNotInBlock,
+		},
+	}
+	for _, test := range tests {
+		reader := &malformedProofReader{
+			receipt:  test.receipt,
+			header:   test.header,
+			receipts: test.receipts,
+		}
+		require.NotPanics(t, func() {
+			_, err := ethereumcli.BuildReceiptProof(context.Background(), reader, base.receipts[1].TxHash)
+			require.ErrorIs(t, err, test.err, test.name)
+		}, test.name)
+	}
+}
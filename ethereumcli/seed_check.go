@@ -0,0 +1,78 @@
+package ethereumcli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"golang.org/x/net/context"
+	"math/big"
+)
+
+var (
+	// ErrTooFewEndpoints 校验节点少于两个，无法防范单个恶意或落后的节点
+	ErrTooFewEndpoints = errors.New("ethereumcli: at least two endpoints required")
+	// ErrSeedBlockNotCanonical 种子块哈希与节点的规范链不一致
+	ErrSeedBlockNotCanonical = errors.New("ethereumcli: seed block is not canonical")
+	// ErrSeedBlockTooShallow 种子块的确认深度不足
+	ErrSeedBlockTooShallow = errors.New("ethereumcli: seed block is not deep enough")
+)
+
+// HeaderReader 按块高查询区块头，*ethclient.Client 实现了该接口
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// CheckSeedBlock 使用种子块哈希前，在每个节点上确认该块仍在规范链上且确认深度不少于 minDepth，
+// 防止恶意或落后的 RPC 节点影响种子。需要至少两个独立的节点
+func CheckSeedBlock(
+	ctx context.Context,
+	number uint64,
+	hash common.Hash,
+	minDepth uint64,
+	endpoints ...HeaderReader,
+) error {
+	if len(endpoints) < 2 {
+		return fmt.Errorf("%w: got %d", ErrTooFewEndpoints, len(endpoints))
+	}
+
+	for i, endpoint := range endpoints {
+		header, err := endpoint.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return fmt.Errorf("endpoint %d: fetch seed block %d: %w", i, number, err)
+		}
+		if header.Hash() != hash {
+			return fmt.Errorf("%w: endpoint %d has %s at block %d, want %s",
+				ErrSeedBlockNotCanonical, i, header.Hash(), number, hash)
+		}
+
+		head, err := endpoint.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("endpoint %d: fetch head: %w", i, err)
+		}
+		headNumber := head.Number.Uint64()
+		if headNumber < number || headNumber-number < minDepth {
+			return fmt.Errorf("%w: endpoint %d head %d, seed block %d, want depth %d",
+				ErrSeedBlockTooShallow, i, headNumber, number, minDepth)
+		}
+	}
+	return nil
+}
+
+// MeasureHeadLag 返回 reference 节点领先 primary 节点的块数，primary 领先时为负数，
+// 并更新 ethereumcli/head/lag/<name> 指标，name 区分不同的节点对
+func MeasureHeadLag(ctx context.Context, name string, primary, reference HeaderReader) (int64, error) {
+	primaryHead, err := primary.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	referenceHead, err := reference.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	lag := new(big.Int).Sub(referenceHead.Number, primaryHead.Number).Int64()
+	metrics.GetOrRegisterGauge("ethereumcli/head/lag/"+name, nil).Update(lag)
+	return lag, nil
+}
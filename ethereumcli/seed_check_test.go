@@ -0,0 +1,75 @@
+package ethereumcli_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/ethereumcli"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// fakeHeaderReader 块高 1 到 head 的区块头，extra 不同的节点得到不同的哈希
+type fakeHeaderReader struct {
+	head  uint64
+	extra []byte
+	err   error
+}
+
+func (r *fakeHeaderReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if number == nil {
+		number = new(big.Int).SetUint64(r.head)
+	}
+	return &types.Header{Number: new(big.Int).Set(number), Extra: r.extra}, nil
+}
+
+func TestCheckSeedBlock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	seed := (&types.Header{Number: big.NewInt(10)}).Hash()
+
+	primary := &fakeHeaderReader{head: 20}
+	reference := &fakeHeaderReader{head: 22}
+	require.Nil(t, ethereumcli.CheckSeedBlock(ctx, 10, seed, 10, primary, reference))
+
+	// 单个节点无法防范恶意或落后的 RPC
+	err := ethereumcli.CheckSeedBlock(ctx, 10, seed, 10, primary)
+	require.ErrorIs(t, err, ethereumcli.ErrTooFewEndpoints)
+
+	forked := &fakeHeaderReader{head: 22, extra: []byte("fork")}
+	err = ethereumcli.CheckSeedBlock(ctx, 10, seed, 10, primary, forked)
+	require.ErrorIs(t, err, ethereumcli.ErrSeedBlockNotCanonical)
+
+	lagging := &fakeHeaderReader{head: 15}
+	err = ethereumcli.CheckSeedBlock(ctx, 10, seed, 10, primary, lagging)
+	require.ErrorIs(t, err, ethereumcli.ErrSeedBlockTooShallow)
+
+	errFetch := errors.New("fetch failed")
+	err = ethereumcli.CheckSeedBlock(ctx, 10, seed, 10, primary, &fakeHeaderReader{err: errFetch})
+	require.ErrorIs(t, err, errFetch)
+}
+
+func TestMeasureHeadLag(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lag, err := ethereumcli.MeasureHeadLag(ctx, "test-a", &fakeHeaderReader{head: 20}, &fakeHeaderReader{head: 23})
+	require.Nil(t, err)
+	require.Equal(t, int64(3), lag)
+
+	lag, err = ethereumcli.MeasureHeadLag(ctx, "test-b", &fakeHeaderReader{head: 20}, &fakeHeaderReader{head: 18})
+	require.Nil(t, err)
+	require.Equal(t, int64(-2), lag)
+
+	// 每对节点有各自的指标
+	require.Equal(t, int64(3), metrics.GetOrRegisterGauge("ethereumcli/head/lag/test-a", nil).Snapshot().Value())
+	require.Equal(t, int64(-2), metrics.GetOrRegisterGauge("ethereumcli/head/lag/test-b", nil).Snapshot().Value())
+}
@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go/kms v1.21.0
 	github.com/decred/dcrd/hdkeychain/v3 v3.1.2
 	github.com/ethereum/go-ethereum v1.15.5
+	github.com/holiman/uint256 v1.3.2
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.10.0
 	github.com/the-web3/contracts-caller v0.0.0-20240810130019-a9347663f740
@@ -51,7 +52,6 @@ require (
 	github.com/googleapis/gax-go/v2 v2.14.1 // indirect
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/holiman/bloomfilter/v2 v2.0.3 // indirect
-	github.com/holiman/uint256 v1.3.2 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/mmcloughlin/addchain v0.4.0 // indirect
 	github.com/olekukonko/tablewriter v0.0.5 // indirect
@@ -0,0 +1,53 @@
+package txmgr
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/net/context"
+	"math/big"
+)
+
+// NonceSource 查询账户的 latest 和 pending nonce，*ethclient.Client 实现了该接口
+type NonceSource interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// Backpressure 发送端的拥堵情况，调度方据此放慢接收新任务，避免拥堵时堆积替换交易
+type Backpressure struct {
+	PendingSends uint64 // 未返回的 Send 数量，含排队等待的
+	NonceSpan    uint64 // 发送地址已广播但未打包的交易数量，未配置 MaxNonceSpan 时为 0
+	Throttle     bool   // 任一指标达到配置的阈值
+}
+
+// PendingSends 返回未返回的 Send 数量
+func (m *SimpleTxManager) PendingSends() uint64 {
+	return uint64(m.pending.Load())
+}
+
+// Backpressure 返回当前的背压状态。查询 nonce 失败时返回错误，PendingSends 仍然有效
+func (m *SimpleTxManager) Backpressure(ctx context.Context) (Backpressure, error) {
+	bp := Backpressure{PendingSends: m.PendingSends()}
+	if m.cfg.MaxPendingSends > 0 && bp.PendingSends >= m.cfg.MaxPendingSends {
+		bp.Throttle = true
+	}
+	if m.cfg.MaxNonceSpan == 0 {
+		return bp, nil
+	}
+
+	nonces := m.backend.(NonceSource)
+	latest, err := nonces.NonceAt(ctx, m.cfg.Sender, nil)
+	if err != nil {
+		return bp, err
+	}
+	pending, err := nonces.PendingNonceAt(ctx, m.cfg.Sender)
+	if err != nil {
+		return bp, err
+	}
+	if pending > latest {
+		bp.NonceSpan = pending - latest
+	}
+	if bp.NonceSpan >= m.cfg.MaxNonceSpan {
+		bp.Throttle = true
+	}
+	return bp, nil
+}
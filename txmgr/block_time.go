@@ -0,0 +1,47 @@
+package txmgr
+
+import (
+	"time"
+)
+
+// 由出块时间推导计时器默认值时使用的倍数和下限
+const (
+	receiptQueriesPerBlock  = 2
+	resubmissionBlocks      = 5
+	minReceiptQueryInterval = 100 * time.Millisecond
+	minResubmissionTimeout  = time.Second
+)
+
+// DefaultBlockTimes 常见链的平均出块时间，按 chain ID 索引
+var DefaultBlockTimes = map[uint64]time.Duration{
+	1:        12 * time.Second,       // Ethereum
+	10:       2 * time.Second,        // OP Mainnet
+	56:       3 * time.Second,        // BNB Smart Chain
+	100:      5 * time.Second,        // Gnosis
+	137:      2 * time.Second,        // Polygon PoS
+	8453:     2 * time.Second,        // Base
+	42161:    250 * time.Millisecond, // Arbitrum One
+	43114:    2 * time.Second,        // Avalanche C-Chain
+	11155111: 12 * time.Second,       // Sepolia
+}
+
+// BlockTimeForChain 返回链的默认出块时间，未知的链返回 false
+func BlockTimeForChain(chainID uint64) (time.Duration, bool) {
+	blockTime, ok := DefaultBlockTimes[chainID]
+	return blockTime, ok
+}
+
+// WithBlockTimeDefaults 配置了 BlockTime 时，为未设置的计时器填入按出块时间推导的默认值，NewSimpleTxManager 会自动调用：
+// 每个块查询两次回执，五个块未打包时重发
+func (cfg Config) WithBlockTimeDefaults() Config {
+	if cfg.BlockTime <= 0 {
+		return cfg
+	}
+	if cfg.ReceiptQueryInterval == 0 {
+		cfg.ReceiptQueryInterval = max(cfg.BlockTime/receiptQueriesPerBlock, minReceiptQueryInterval)
+	}
+	if cfg.ResubmissionTimeout == 0 {
+		cfg.ResubmissionTimeout = max(cfg.BlockTime*resubmissionBlocks, minResubmissionTimeout)
+	}
+	return cfg
+}
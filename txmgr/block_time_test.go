@@ -0,0 +1,122 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestWithBlockTimeDefaults(t *testing.T) {
+	cfg := txmgr.Config{BlockTime: 2 * time.Second}.WithBlockTimeDefaults()
+	require.Equal(t, time.Second, cfg.ReceiptQueryInterval)
+	require.Equal(t, 10*time.Second, cfg.ResubmissionTimeout)
+
+	// Explicit timers are kept.
+	cfg = txmgr.Config{BlockTime: 12 * time.Second, ReceiptQueryInterval: time.Second}.WithBlockTimeDefaults()
+	require.Equal(t, time.Second, cfg.ReceiptQueryInterval)
+	require.Equal(t, time.Minute, cfg.ResubmissionTimeout)
+
+	blockTime, ok := txmgr.BlockTimeForChain(42161)
+	require.True(t, ok)
+	cfg = txmgr.Config{BlockTime: blockTime}.WithBlockTimeDefaults()
+	require.Equal(t, 125*time.Millisecond, cfg.ReceiptQueryInterval)
+	require.Equal(t, 1250*time.Millisecond, cfg.ResubmissionTimeout)
+
+	// Very fast chains are clamped to the minimum intervals.
+	cfg = txmgr.Config{BlockTime: 100 * time.Millisecond}.WithBlockTimeDefaults()
+	require.Equal(t, 100*time.Millisecond, cfg.ReceiptQueryInterval)
+	require.Equal(t, time.Second, cfg.ResubmissionTimeout)
+
+	cfg = txmgr.Config{}.WithBlockTimeDefaults()
+	require.Zero(t, cfg.ReceiptQueryInterval)
+	require.Zero(t, cfg.ResubmissionTimeout)
+
+	_, ok = txmgr.BlockTimeForChain(0)
+	require.False(t, ok)
+}
+
+func TestNewSimpleTxManagerWithBlockTime(t *testing.T) {
+	_, err := txmgr.NewSimpleTxManager(txmgr.Config{
+		BlockTime:                 2 * time.Second,
+		NumConfirmations:          1,
+		SafeAbortNonceTooLowCount: 3,
+	}, newMockBackend())
+	require.Nil(t, err)
+
+	_, err = txmgr.NewSimpleTxManager(txmgr.Config{
+		NumConfirmations:          1,
+		SafeAbortNonceTooLowCount: 3,
+	}, newMockBackend())
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
+
+// countingReceiptSource 记录回执查询次数的 FakeReceiptSource
+type countingReceiptSource struct {
+	*txmgrtest.FakeReceiptSource
+
+	receiptCalls atomic.Int64
+}
+
+func (b *countingReceiptSource) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.receiptCalls.Add(1)
+	return b.FakeReceiptSource.TransactionReceipt(ctx, txHash)
+}
+
+func TestTxMgrDefersPollingUntilNearlyConfirmed(t *testing.T) {
+	t.Parallel()
+
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	backend := &countingReceiptSource{FakeReceiptSource: txmgrtest.NewFakeReceiptSource()}
+	sender := txmgrtest.NewFakeSender(backend.FakeReceiptSource, true)
+	mgr := newTxManager(t, txmgr.Config{
+		BlockTime:                 2 * time.Second,
+		NumConfirmations:          10,
+		SafeAbortNonceTooLowCount: 3,
+		Clock:                     clock,
+	}, backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(20),
+		}), nil
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := mgr.Send(context.Background(), updateGasPrice, sender.Send)
+		errc <- err
+	}()
+
+	// 交易打包在块 1，首轮查询拿到回执后还差 9 个确认，推迟 8 个出块时间（16 秒）再查询
+	require.Eventually(t, func() bool {
+		return backend.receiptCalls.Load() == 1
+	}, 5*time.Second, time.Millisecond)
+	for i := 0; i < 15; i++ {
+		clock.Advance(time.Second)
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.Equal(t, int64(1), backend.receiptCalls.Load())
+
+	for i := 0; i < 9; i++ {
+		backend.Mine()
+	}
+	for {
+		select {
+		case err := <-errc:
+			require.Nil(t, err)
+			require.Equal(t, int64(2), backend.receiptCalls.Load())
+			return
+		case <-time.After(5 * time.Millisecond):
+			clock.Advance(time.Second)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package txmgr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/net/context"
+	"math/big"
+	"sync"
+)
+
+// ErrExceedsBudget 单笔交易的最高手续费超过了整个预算，永远无法发送
+var ErrExceedsBudget = errors.New("txmgr: transaction exceeds spend budget")
+
+// SpendBudget 限制所有在途交易的最高手续费之和（gas 上限乘以 gasFeeCap），
+// 多个发送地址共享同一个 SpendBudget，防止费用飙升时整体花费失控
+type SpendBudget struct {
+	limit *big.Int
+
+	mu    sync.Mutex
+	inUse *big.Int
+	freed chan struct{} // 释放额度时关闭并替换，唤醒等待的调用方
+}
+
+func NewSpendBudget(limit *big.Int) *SpendBudget {
+	return &SpendBudget{
+		limit: new(big.Int).Set(limit),
+		inUse: new(big.Int),
+		freed: make(chan struct{}),
+	}
+}
+
+// InUse 返回已占用的额度
+func (b *SpendBudget) InUse() *big.Int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return new(big.Int).Set(b.inUse)
+}
+
+// Acquire 占用 amount 额度，额度不足时等待其他交易释放，直到 ctx 结束
+func (b *SpendBudget) Acquire(ctx context.Context, amount *big.Int) error {
+	if amount.Cmp(b.limit) > 0 {
+		return fmt.Errorf("%w: %s > %s", ErrExceedsBudget, amount, b.limit)
+	}
+	for {
+		b.mu.Lock()
+		if b.fits(amount) {
+			b.inUse.Add(b.inUse, amount)
+			b.mu.Unlock()
+			return nil
+		}
+		freed := b.freed
+		b.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryAcquire 额度充足时占用 amount 并返回 true，否则立即返回 false
+func (b *SpendBudget) TryAcquire(amount *big.Int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.fits(amount) {
+		return false
+	}
+	b.inUse.Add(b.inUse, amount)
+	return true
+}
+
+// Release 释放 amount 额度
+func (b *SpendBudget) Release(amount *big.Int) {
+	if amount.Sign() == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inUse.Sub(b.inUse, amount)
+	if b.inUse.Sign() < 0 {
+		b.inUse.SetInt64(0)
+	}
+	close(b.freed)
+	b.freed = make(chan struct{})
+}
+
+func (b *SpendBudget) fits(amount *big.Int) bool {
+	return new(big.Int).Add(b.inUse, amount).Cmp(b.limit) <= 0
+}
+
+// MaxTxFee 交易可能支付的最高手续费，即 gas 上限乘以 gasFeeCap
+func MaxTxFee(tx *types.Transaction) *big.Int {
+	return new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+}
+
+// BudgetedTxManager 包装 TxManager，发送前从共享的 SpendBudget 占用交易的最高手续费。
+// 同一 nonce 的替换交易只有一笔会上链，占用的是其中的最大值；
+// 首笔交易在进入 next.Send 之前排队等待额度，不占用并发名额也不触发重发，
+// 额度到手后重新构建交易，避免排队期间费用或 nonce 过时；
+// 之后加价额度不足时返回 ErrSkipBump，继续等待在途交易而不加价
+type BudgetedTxManager struct {
+	next   TxManager
+	budget *SpendBudget
+}
+
+func NewBudgetedTxManager(next TxManager, budget *SpendBudget) *BudgetedTxManager {
+	return &BudgetedTxManager{next: next, budget: budget}
+}
+
+func (m *BudgetedTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*SendResult, error) {
+	firstTx, reserved, err := m.reserveFirst(ctx, updateGasPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		lastTx *types.Transaction
+	)
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		m.budget.Release(reserved)
+	}()
+
+	budgetedUpdateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		mu.Lock()
+		if lastTx == nil {
+			// 首次发送使用已占用额度的交易
+			lastTx = firstTx
+			mu.Unlock()
+			return firstTx, nil
+		}
+		mu.Unlock()
+
+		tx, err := updateGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		extra := new(big.Int).Sub(MaxTxFee(tx), reserved)
+		if extra.Sign() > 0 {
+			if !m.budget.TryAcquire(extra) {
+				log.Warn("ContractsCaller spend budget exhausted, keeping in-flight transaction without fee bump",
+					"hash", lastTx.Hash(), "inUse", m.budget.InUse())
+				return nil, ErrSkipBump
+			}
+			reserved.Add(reserved, extra)
+		}
+		lastTx = tx
+		return tx, nil
+	}
+
+	return m.next.Send(ctx, budgetedUpdateGasPrice, sendTx)
+}
+
+// reserveFirst 为首笔交易占用额度并返回该交易。额度充足时直接使用构建的交易；
+// 不足时以其最高手续费为估计值排队等待，拿到额度后重新构建交易再核对额度
+func (m *BudgetedTxManager) reserveFirst(ctx context.Context, updateGasPrice UpdateGasPriceFunc) (*types.Transaction, *big.Int, error) {
+	reserved := new(big.Int)
+	for {
+		tx, err := updateGasPrice(ctx)
+		if err != nil {
+			m.budget.Release(reserved)
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			return nil, nil, fmt.Errorf("%w: %w", ErrUpdateGasPrice, err)
+		}
+
+		need := MaxTxFee(tx)
+		switch diff := new(big.Int).Sub(need, reserved); {
+		case diff.Sign() <= 0:
+			m.budget.Release(new(big.Int).Neg(diff))
+			return tx, need, nil
+		case m.budget.TryAcquire(diff):
+			return tx, need, nil
+		}
+
+		// 额度不足，按最新的估计值排队，拿到额度后重新构建交易
+		m.budget.Release(reserved)
+		log.Debug("ContractsCaller waiting for spend budget", "maxFee", need, "inUse", m.budget.InUse())
+		if err := m.budget.Acquire(ctx, need); err != nil {
+			return nil, nil, err
+		}
+		reserved = need
+	}
+}
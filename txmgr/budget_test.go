@@ -0,0 +1,192 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSpendBudget(t *testing.T) {
+	t.Parallel()
+
+	budget := txmgr.NewSpendBudget(big.NewInt(100))
+	ctx := context.Background()
+
+	require.ErrorIs(t, budget.Acquire(ctx, big.NewInt(101)), txmgr.ErrExceedsBudget)
+	require.Nil(t, budget.Acquire(ctx, big.NewInt(60)))
+	require.False(t, budget.TryAcquire(big.NewInt(50)))
+	require.True(t, budget.TryAcquire(big.NewInt(40)))
+	require.Equal(t, big.NewInt(100), budget.InUse())
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- budget.Acquire(ctx, big.NewInt(50))
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	budget.Release(big.NewInt(60))
+	require.Nil(t, <-acquired)
+	require.Equal(t, big.NewInt(90), budget.InUse())
+
+	ctxt, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, budget.Acquire(ctxt, big.NewInt(20)))
+}
+
+// budgetTx 返回 gas 上限为 1、feeCap 为 gasFeeCap 的交易，其最高手续费等于 gasFeeCap
+func budgetTx(nonce uint64, gasFeeCap int64) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(gasFeeCap),
+		Gas:       1,
+	})
+}
+
+func TestBudgetedTxManagerSkipsBumpOverBudget(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	budget := txmgr.NewSpendBudget(big.NewInt(150))
+	mgr := txmgr.NewBudgetedTxManager(h.mgr, budget)
+
+	var (
+		mu    sync.Mutex
+		sent  []common.Hash
+		fee   int64 = 100
+		bumps int
+	)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		tx := budgetTx(0, fee)
+		if fee > 100 {
+			// 加价超出预算，此时让首笔交易上链
+			bumps++
+			txHash := sent[0]
+			h.backend.mine(&txHash, big.NewInt(100))
+		}
+		fee *= 2
+		return tx, nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		sent = append(sent, tx.Hash())
+		return nil
+	}
+
+	result, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.Equal(t, 1, bumps)
+	// 超出预算的加价不重发，也不计入 Attempts
+	require.Len(t, sent, 1)
+	require.Equal(t, sent[0], result.Receipt.TxHash)
+	require.Equal(t, uint64(1), result.Attempts)
+	require.Equal(t, new(big.Int), budget.InUse())
+}
+
+func TestBudgetedTxManagerQueuesOverBudget(t *testing.T) {
+	t.Parallel()
+
+	budget := txmgr.NewSpendBudget(big.NewInt(150))
+	first := txmgr.NewBudgetedTxManager(newTestHarness(t).mgr, budget)
+	second := txmgr.NewBudgetedTxManager(newTestHarness(t).mgr, budget)
+
+	started := make(chan struct{}, 1)
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		// Never mine, keeping the first Send in flight.
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := first.Send(ctx1, func(ctx context.Context) (*types.Transaction, error) {
+			return budgetTx(0, 100), nil
+		}, sendTx)
+		errc <- err
+	}()
+	<-started
+
+	queuedSendTx := func(ctx context.Context, tx *types.Transaction) error {
+		t.Error("queued Send should not broadcast a transaction")
+		return nil
+	}
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel2()
+	result, err := second.Send(ctx2, func(ctx context.Context) (*types.Transaction, error) {
+		return budgetTx(0, 100), nil
+	}, queuedSendTx)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Nil(t, result)
+
+	cancel1()
+	require.Equal(t, context.Canceled, <-errc)
+	require.Equal(t, new(big.Int), budget.InUse())
+}
+
+func TestBudgetedTxManagerQueuesBeforeResubmission(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	budget := txmgr.NewSpendBudget(big.NewInt(150))
+	require.Nil(t, budget.Acquire(context.Background(), big.NewInt(100)))
+	mgr := txmgr.NewBudgetedTxManager(h.mgr, budget)
+
+	var builds atomic.Int64
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return budgetTx(uint64(builds.Add(1)), 100), nil
+	}
+
+	sender := txmgrtest.NewFakeSender(nil, false)
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if err := sender.Send(ctx, tx); err != nil {
+			return err
+		}
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	// 排队时间超过 ResubmissionTimeout，等待额度期间不应构建替换交易
+	var buildsWhileQueued, sentWhileQueued int
+	time.AfterFunc(1500*time.Millisecond, func() {
+		buildsWhileQueued = int(builds.Load())
+		sentWhileQueued = len(sender.Sent())
+		budget.Release(big.NewInt(100))
+	})
+
+	result, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.Equal(t, 1, buildsWhileQueued)
+	require.Equal(t, 0, sentWhileQueued)
+	// 拿到额度后重新构建交易，发送的是排队结束后构建的交易
+	require.Equal(t, int64(2), builds.Load())
+	require.Len(t, sender.Sent(), 1)
+	require.Equal(t, uint64(2), sender.Sent()[0].Nonce())
+	require.Equal(t, sender.Sent()[0].Hash(), result.Receipt.TxHash)
+	require.Equal(t, uint64(1), result.Attempts)
+	require.Equal(t, new(big.Int), budget.InUse())
+}
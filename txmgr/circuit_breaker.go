@@ -0,0 +1,244 @@
+package txmgr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/net/context"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于断开状态，Send 未执行
+var ErrCircuitOpen = errors.New("txmgr: circuit breaker open")
+
+// BreakerState 熔断器状态
+type BreakerState uint8
+
+const (
+	BreakerClosed   BreakerState = iota // 正常放行
+	BreakerOpen                         // 失败率过高，拒绝所有 Send
+	BreakerHalfOpen                     // 冷却结束，放行一笔探测交易
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", uint8(s))
+	}
+}
+
+// CircuitBreakerConfig CircuitBreaker 的配置，除 Clock 和 Canary 外的字段都必须设置，零值会被 NewCircuitBreaker 拒绝
+type CircuitBreakerConfig struct {
+	Window      time.Duration // 统计失败率的时间窗口，必须大于 0
+	MinSamples  int           // 窗口内的结果数达到该值后才判断失败率，避免少量样本误触发，必须大于 0
+	FailureRate float64       // 窗口内失败率达到该值时断开，取值 (0, 1]，1 表示全部失败才断开
+	Cooldown    time.Duration // 断开后等待该时长再放行一笔探测交易，设置 Canary 时也是 Run 发送探测的间隔，必须大于 0
+	Clock       Clock         // 时间来源，为空时使用 SystemClock
+
+	// Canary 冷却结束后由 Run 发送的探测交易（例如向自己转账 0 ETH），返回 nil 表示链和合约恢复正常。
+	// 设置后半开状态只放行 Canary，调用方的 Send 在探测成功前都被拒绝；为空时以冷却后的下一笔 Send 作为探测
+	Canary func(ctx context.Context) error
+}
+
+// validate 检查配置是否合法
+func (cfg CircuitBreakerConfig) validate() error {
+	switch {
+	case cfg.Window <= 0:
+		return fmt.Errorf("%w: Window must be > 0", ErrInvalidConfig)
+	case cfg.MinSamples <= 0:
+		return fmt.Errorf("%w: MinSamples must be > 0", ErrInvalidConfig)
+	case cfg.FailureRate <= 0 || cfg.FailureRate > 1:
+		return fmt.Errorf("%w: FailureRate must be within (0, 1]", ErrInvalidConfig)
+	case cfg.Cooldown <= 0:
+		return fmt.Errorf("%w: Cooldown must be > 0", ErrInvalidConfig)
+	}
+	return nil
+}
+
+type sendOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// CircuitBreaker 包装 TxManager，失败率过高（通常是 RPC 或合约异常）时暂停发送，
+// 冷却后发送一笔探测交易（Canary 或放行的第一笔 Send），成功则恢复，失败则重新断开。
+// 调用方 ctx 结束（取消、超时或请求过期）导致的失败不代表链或合约异常，不计入统计
+type CircuitBreaker struct {
+	next TxManager
+	cfg  CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	outcomes []sendOutcome
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(next TxManager, cfg CircuitBreakerConfig) (*CircuitBreaker, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	return &CircuitBreaker{next: next, cfg: cfg}, nil
+}
+
+// State 返回熔断器当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+func (b *CircuitBreaker) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*SendResult, error) {
+	probe, err := b.admit()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := b.next.Send(ctx, updateGasPrice, sendTx)
+	if err != nil && ctx.Err() != nil {
+		b.skipProbe(probe)
+		return result, err
+	}
+
+	failed := err != nil || result == nil || result.Receipt.Status == types.ReceiptStatusFailed
+	b.record(probe, failed)
+	return result, err
+}
+
+// Run 配置了 Canary 时，每隔 Cooldown 检查一次，半开状态下发送探测交易，直到 ctx 结束
+func (b *CircuitBreaker) Run(ctx context.Context) {
+	if b.cfg.Canary == nil {
+		return
+	}
+
+	ticker := b.cfg.Clock.NewTicker(b.cfg.Cooldown)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.Chan():
+			b.probeCanary(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probeCanary 半开状态下发送一笔探测交易并记录结果
+func (b *CircuitBreaker) probeCanary(ctx context.Context) {
+	b.mu.Lock()
+	b.advance()
+	if b.state != BreakerHalfOpen || b.probing {
+		b.mu.Unlock()
+		return
+	}
+	b.probing = true
+	b.mu.Unlock()
+
+	err := b.cfg.Canary(ctx)
+	if err != nil && ctx.Err() != nil {
+		b.skipProbe(true)
+		return
+	}
+	if err != nil {
+		log.Warn("ContractsCaller circuit breaker canary failed", "err", err)
+	}
+	b.record(true, err != nil)
+}
+
+// skipProbe 探测没有得到结果，允许下一次探测
+func (b *CircuitBreaker) skipProbe(probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// admit 判断本次 Send 是否放行，返回是否为探测交易
+func (b *CircuitBreaker) admit() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	switch b.state {
+	case BreakerOpen:
+		return false, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing || b.cfg.Canary != nil {
+			return false, ErrCircuitOpen
+		}
+		b.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// advance 冷却时间结束后由断开转为半开
+func (b *CircuitBreaker) advance() {
+	if b.state == BreakerOpen && b.cfg.Clock.Now().Sub(b.openedAt) >= b.cfg.Cooldown {
+		b.state = BreakerHalfOpen
+		log.Info("ContractsCaller circuit breaker cooldown elapsed, waiting for probe transaction")
+	}
+}
+
+func (b *CircuitBreaker) record(probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.cfg.Clock.Now()
+	if probe {
+		b.probing = false
+		if failed {
+			b.trip(now)
+			return
+		}
+		b.state = BreakerClosed
+		b.outcomes = nil
+		log.Info("ContractsCaller circuit breaker probe succeeded, resuming sends")
+		return
+	}
+
+	b.outcomes = append(b.outcomes, sendOutcome{at: now, failed: failed})
+	cutoff := now.Add(-b.cfg.Window)
+	for len(b.outcomes) > 0 && b.outcomes[0].at.Before(cutoff) {
+		b.outcomes = b.outcomes[1:]
+	}
+	if b.state != BreakerClosed || len(b.outcomes) < b.cfg.MinSamples {
+		return
+	}
+
+	var failures int
+	for _, o := range b.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+	if rate := float64(failures) / float64(len(b.outcomes)); rate >= b.cfg.FailureRate {
+		log.Error("ContractsCaller send failure rate too high, opening circuit breaker",
+			"failures", failures, "samples", len(b.outcomes), "rate", rate)
+		b.trip(now)
+	}
+}
+
+func (b *CircuitBreaker) trip(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.outcomes = nil
+}
@@ -0,0 +1,201 @@
+package txmgr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errSendFailed = errors.New("send failed")
+
+// stubTxManager 每次 Send 返回相同结果的 TxManager
+type stubTxManager struct {
+	calls  int
+	err    error
+	status uint64
+}
+
+func (m *stubTxManager) Send(
+	ctx context.Context,
+	updateGasPrice txmgr.UpdateGasPriceFunc,
+	sendTx txmgr.SendTransactionFunc,
+) (*txmgr.SendResult, error) {
+
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &txmgr.SendResult{Receipt: &types.Receipt{Status: m.status}}, nil
+}
+
+func newCircuitBreaker(t *testing.T, next txmgr.TxManager) (*txmgr.CircuitBreaker, *txmgrtest.FakeClock) {
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	breaker, err := txmgr.NewCircuitBreaker(next, txmgr.CircuitBreakerConfig{
+		Window:      time.Minute,
+		MinSamples:  4,
+		FailureRate: 0.5,
+		Cooldown:    time.Minute,
+		Clock:       clock,
+	})
+	require.Nil(t, err)
+	return breaker, clock
+}
+
+func sendN(breaker *txmgr.CircuitBreaker, n int) error {
+	var err error
+	for i := 0; i < n; i++ {
+		_, err = breaker.Send(context.Background(), nil, nil)
+	}
+	return err
+}
+
+func TestCircuitBreakerOpensOnFailureRate(t *testing.T) {
+	t.Parallel()
+
+	next := &stubTxManager{status: types.ReceiptStatusSuccessful}
+	breaker, _ := newCircuitBreaker(t, next)
+
+	require.Nil(t, sendN(breaker, 2))
+	next.err = errSendFailed
+	require.ErrorIs(t, sendN(breaker, 2), errSendFailed)
+	require.Equal(t, txmgr.BreakerOpen, breaker.State())
+
+	_, err := breaker.Send(context.Background(), nil, nil)
+	require.ErrorIs(t, err, txmgr.ErrCircuitOpen)
+	require.Equal(t, 4, next.calls)
+}
+
+func TestCircuitBreakerCountsRevertedReceipts(t *testing.T) {
+	t.Parallel()
+
+	next := &stubTxManager{status: types.ReceiptStatusFailed}
+	breaker, _ := newCircuitBreaker(t, next)
+
+	require.Nil(t, sendN(breaker, 4))
+	require.Equal(t, txmgr.BreakerOpen, breaker.State())
+}
+
+func TestCircuitBreakerIgnoresOldOutcomes(t *testing.T) {
+	t.Parallel()
+
+	next := &stubTxManager{err: errSendFailed}
+	breaker, clock := newCircuitBreaker(t, next)
+
+	sendN(breaker, 3)
+	clock.Advance(2 * time.Minute)
+	next.err = nil
+	next.status = types.ReceiptStatusSuccessful
+	require.Nil(t, sendN(breaker, 4))
+	require.Equal(t, txmgr.BreakerClosed, breaker.State())
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	t.Parallel()
+
+	next := &stubTxManager{err: errSendFailed}
+	breaker, clock := newCircuitBreaker(t, next)
+
+	sendN(breaker, 4)
+	require.Equal(t, txmgr.BreakerOpen, breaker.State())
+
+	// 探测失败，重新断开并重新计算冷却时间
+	clock.Advance(time.Minute)
+	require.Equal(t, txmgr.BreakerHalfOpen, breaker.State())
+	_, err := breaker.Send(context.Background(), nil, nil)
+	require.ErrorIs(t, err, errSendFailed)
+	require.Equal(t, txmgr.BreakerOpen, breaker.State())
+	_, err = breaker.Send(context.Background(), nil, nil)
+	require.ErrorIs(t, err, txmgr.ErrCircuitOpen)
+
+	// 探测成功，恢复放行
+	clock.Advance(time.Minute)
+	next.err = nil
+	next.status = types.ReceiptStatusSuccessful
+	_, err = breaker.Send(context.Background(), nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, txmgr.BreakerClosed, breaker.State())
+	require.Equal(t, 6, next.calls)
+}
+
+func TestCircuitBreakerIgnoresEndedContexts(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadlineCause(context.Background(), time.Unix(0, 0), txmgr.ErrRequestExpired)
+	defer cancelExpired()
+
+	for _, ctx := range []context.Context{canceled, expired} {
+		next := &stubTxManager{err: ctx.Err()}
+		breaker, _ := newCircuitBreaker(t, next)
+		for i := 0; i < 4; i++ {
+			_, err := breaker.Send(ctx, nil, nil)
+			require.ErrorIs(t, err, ctx.Err())
+		}
+		require.Equal(t, txmgr.BreakerClosed, breaker.State())
+	}
+}
+
+func TestCircuitBreakerCanary(t *testing.T) {
+	t.Parallel()
+
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	next := &stubTxManager{err: errSendFailed}
+	canaries := make(chan error)
+	breaker, err := txmgr.NewCircuitBreaker(next, txmgr.CircuitBreakerConfig{
+		Window:      time.Minute,
+		MinSamples:  4,
+		FailureRate: 0.5,
+		Cooldown:    time.Minute,
+		Clock:       clock,
+		Canary: func(ctx context.Context) error {
+			return <-canaries
+		},
+	})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go breaker.Run(ctx)
+	clock.BlockUntil(1)
+
+	sendN(breaker, 4)
+	require.Equal(t, txmgr.BreakerOpen, breaker.State())
+
+	// 半开状态只放行探测交易，调用方的 Send 仍被拒绝
+	clock.Advance(time.Minute)
+	_, err = breaker.Send(context.Background(), nil, nil)
+	require.ErrorIs(t, err, txmgr.ErrCircuitOpen)
+
+	canaries <- errSendFailed
+	require.Eventually(t, func() bool {
+		return breaker.State() == txmgr.BreakerOpen
+	}, time.Second, time.Millisecond)
+
+	// 没有调用方流量时也能恢复
+	clock.Advance(time.Minute)
+	canaries <- nil
+	require.Eventually(t, func() bool {
+		return breaker.State() == txmgr.BreakerClosed
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 4, next.calls)
+}
+
+func TestCircuitBreakerInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := txmgr.NewCircuitBreaker(&stubTxManager{}, txmgr.CircuitBreakerConfig{
+		Window:      time.Minute,
+		MinSamples:  1,
+		FailureRate: 1.5,
+		Cooldown:    time.Minute,
+	})
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
@@ -0,0 +1,74 @@
+package txmgr
+
+import (
+	"context"
+	"time"
+)
+
+// Clock txmgr 使用的时间来源，测试中可替换为可控的实现，见 txmgrtest.FakeClock
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker 与 time.Ticker 对应的接口
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// SystemClock 使用系统时间的 Clock
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) Chan() <-chan time.Time {
+	return t.C
+}
+
+// WithClockDeadline 与 context.WithDeadlineCause 相同，但按 clock 计时。
+// 使用 SystemClock 时直接调用 context.WithDeadlineCause；使用其他 Clock（如 txmgrtest.FakeClock）时
+// 由 clock 的定时器取消 context，此时 Err 为 context.Canceled、context.Cause 为 cause，
+// Deadline 返回 clock 时间线上的截止时间
+func WithClockDeadline(ctx context.Context, clock Clock, deadline time.Time, cause error) (context.Context, context.CancelFunc) {
+	if clock == nil || clock == SystemClock {
+		return context.WithDeadlineCause(ctx, deadline, cause)
+	}
+
+	ctxc, cancel := context.WithCancelCause(ctx)
+	timer := clock.After(deadline.Sub(clock.Now()))
+	go func() {
+		select {
+		case <-timer:
+			cancel(cause)
+		case <-ctxc.Done():
+		}
+	}()
+	return &clockDeadlineCtx{Context: ctxc, deadline: deadline}, func() { cancel(context.Canceled) }
+}
+
+type clockDeadlineCtx struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *clockDeadlineCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
@@ -0,0 +1,120 @@
+package txmgr
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+	"sync"
+)
+
+// maxReplacedTxs ManagedContractBackend 最多记录的被替换交易数量
+const maxReplacedTxs = 1024
+
+// ContractBackend 可读写合约并查询回执的 backend，*ethclient.Client 实现了该接口
+type ContractBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// ManagedContractBackend 在 ContractBackend 上通过 TxManager 发送交易，
+// abigen 生成的合约绑定可直接使用它获得自动重发和加价。
+// SendTransaction 在交易确认后才返回；交易被加价替换时，
+// 用原交易哈希调用 TransactionReceipt（例如 bind.WaitMined）返回实际上链交易的回执
+type ManagedContractBackend struct {
+	ContractBackend
+
+	mgr         TxManager
+	from        common.Address
+	signer      bind.SignerFn
+	bumpPercent uint64
+
+	mu       sync.Mutex
+	replaced map[common.Hash]*types.Receipt
+	order    []common.Hash
+}
+
+var _ ContractBackend = (*ManagedContractBackend)(nil)
+
+// NewManagedContractBackend 创建托管发送的 backend，from 和 signer 需要与合约绑定的 TransactOpts 一致，
+// 每次重发时费用提高 bumpPercent
+func NewManagedContractBackend(
+	backend ContractBackend,
+	mgr TxManager,
+	from common.Address,
+	signer bind.SignerFn,
+	bumpPercent uint64,
+) (*ManagedContractBackend, error) {
+	if bumpPercent < MinReplacementBumpPercent {
+		return nil, fmt.Errorf("%w: bump must be at least %d%%", ErrInvalidConfig, MinReplacementBumpPercent)
+	}
+	return &ManagedContractBackend{
+		ContractBackend: backend,
+		mgr:             mgr,
+		from:            from,
+		signer:          signer,
+		bumpPercent:     bumpPercent,
+		replaced:        make(map[common.Hash]*types.Receipt),
+	}, nil
+}
+
+// SendTransaction 首次发送已签名的 tx，之后每次重发以提高后的费用重新签名同一 nonce 的交易，直到确认
+func (b *ManagedContractBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	var (
+		mu      sync.Mutex
+		current *types.Transaction
+	)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if current == nil {
+			current = tx
+			return current, nil
+		}
+		txData, err := replacementTxData(current,
+			bumpFee(current.GasTipCap(), b.bumpPercent), bumpFee(current.GasFeeCap(), b.bumpPercent))
+		if err != nil {
+			return nil, err
+		}
+		signed, err := b.signer(b.from, types.NewTx(txData))
+		if err != nil {
+			return nil, err
+		}
+		current = signed
+		return current, nil
+	}
+
+	result, err := b.mgr.Send(ctx, updateGasPrice, b.ContractBackend.SendTransaction)
+	if err != nil {
+		return err
+	}
+	if result.Receipt.TxHash != tx.Hash() {
+		b.recordReplacement(tx.Hash(), result.Receipt)
+	}
+	return nil
+}
+
+// TransactionReceipt 原交易被替换时返回替换交易的回执，否则直接查询 backend
+func (b *ManagedContractBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	receipt, ok := b.replaced[txHash]
+	b.mu.Unlock()
+	if ok {
+		return receipt, nil
+	}
+	return b.ContractBackend.TransactionReceipt(ctx, txHash)
+}
+
+func (b *ManagedContractBackend) recordReplacement(original common.Hash, receipt *types.Receipt) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.order) >= maxReplacedTxs {
+		delete(b.replaced, b.order[0])
+		b.order = b.order[1:]
+	}
+	b.replaced[original] = receipt
+	b.order = append(b.order, original)
+}
@@ -0,0 +1,101 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	dcommon "github.com/DQYXACML/dapplink-vrf/common"
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeContractBackend 记录广播的交易，第 mineAt 笔交易被打包
+type fakeContractBackend struct {
+	bind.ContractBackend
+
+	backend *mockBackend
+	mineAt  int
+
+	mu   sync.Mutex
+	sent []*types.Transaction
+}
+
+func (b *fakeContractBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sent = append(b.sent, tx)
+	if len(b.sent) == b.mineAt {
+		txHash := tx.Hash()
+		b.backend.mine(&txHash, tx.GasFeeCap())
+	}
+	return nil
+}
+
+func (b *fakeContractBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return b.backend.TransactionReceipt(ctx, txHash)
+}
+
+func TestManagedContractBackendReplacesTransaction(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	contracts := &fakeContractBackend{backend: h.backend, mineAt: 2}
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1)
+	signer := dcommon.PrivateKeySignerFn(key, chainID)
+
+	managed, err := txmgr.NewManagedContractBackend(contracts, h.mgr, from, signer, 20)
+	require.Nil(t, err)
+
+	to := common.HexToAddress("0x01")
+	tx, err := signer(from, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     4,
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(1000),
+		Gas:       21000,
+		To:        &to,
+	}))
+	require.Nil(t, err)
+
+	require.Nil(t, managed.SendTransaction(context.Background(), tx))
+
+	contracts.mu.Lock()
+	sent := contracts.sent
+	contracts.mu.Unlock()
+	require.Len(t, sent, 2)
+	require.Equal(t, tx.Hash(), sent[0].Hash())
+	require.Equal(t, uint64(4), sent[1].Nonce())
+	require.Equal(t, big.NewInt(120), sent[1].GasTipCap())
+	require.Equal(t, big.NewInt(1200), sent[1].GasFeeCap())
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), sent[1])
+	require.Nil(t, err)
+	require.Equal(t, from, sender)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, managed, tx)
+	require.Nil(t, err)
+	require.Equal(t, sent[1].Hash(), receipt.TxHash)
+}
+
+func TestManagedContractBackendRejectsSmallBump(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	_, err := txmgr.NewManagedContractBackend(&fakeContractBackend{backend: h.backend}, h.mgr,
+		common.Address{}, nil, 5)
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
@@ -0,0 +1,160 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/log"
+	"sync"
+	"time"
+)
+
+// BlockhashWindow EVM 中 BLOCKHASH 只能取到最近 256 个块的哈希
+const BlockhashWindow uint64 = 256
+
+// ErrRequestExpired 请求已经过期，无法再履约
+var ErrRequestExpired = errors.New("txmgr: request already expired")
+
+// BlockhashExpiryBlock 返回请求种子块哈希不再可用的块高
+func BlockhashExpiryBlock(requestBlock uint64) uint64 {
+	return requestBlock + BlockhashWindow
+}
+
+// ExpiryDeadline 根据当前块高、过期块高和出块时间估算截止时间
+func ExpiryDeadline(now time.Time, currentBlock, expiryBlock uint64, blockTime time.Duration) (time.Time, error) {
+	if currentBlock >= expiryBlock {
+		return time.Time{}, ErrRequestExpired
+	}
+	remaining := time.Duration(expiryBlock-currentBlock) * blockTime
+	return now.Add(remaining), nil
+}
+
+// WithExpiryBlock 返回在 expiryBlock 到达前取消的 context，context.Cause 为 ErrRequestExpired。
+// 将其传给 Send 后，请求过期时 txmgr 会停止加价重发。截止时间按 clock 计算，clock 为空时使用 SystemClock，见 WithClockDeadline
+func WithExpiryBlock(
+	ctx context.Context,
+	backend ReceiptSource,
+	expiryBlock uint64,
+	blockTime time.Duration,
+	clock Clock,
+) (context.Context, context.CancelFunc, error) {
+	if clock == nil {
+		clock = SystemClock
+	}
+	currentBlock, err := backend.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	deadline, err := ExpiryDeadline(clock.Now(), currentBlock, expiryBlock, blockTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctxd, cancel := WithClockDeadline(ctx, clock, deadline, ErrRequestExpired)
+	return ctxd, cancel, nil
+}
+
+// BlockDeadlines 用一个轮询循环跟踪链上块高，为多个请求提供在指定块高结束的 context，
+// 避免每个请求各自轮询。有请求等待时才轮询，全部结束后循环退出
+type BlockDeadlines struct {
+	backend      ReceiptSource
+	pollInterval time.Duration
+	clock        Clock
+
+	mu      sync.Mutex
+	waiters map[*blockDeadline]struct{}
+	polling bool
+}
+
+type blockDeadline struct {
+	block  uint64
+	cancel context.CancelCauseFunc
+}
+
+// NewBlockDeadlines 返回按 pollInterval 查询块高的 BlockDeadlines，clock 为空时使用 SystemClock
+func NewBlockDeadlines(backend ReceiptSource, pollInterval time.Duration, clock Clock) *BlockDeadlines {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &BlockDeadlines{
+		backend:      backend,
+		pollInterval: pollInterval,
+		clock:        clock,
+		waiters:      make(map[*blockDeadline]struct{}),
+	}
+}
+
+// WithBlockDeadline 返回链上块高达到 deadlineBlock 时取消的 context，context.Cause 为 ErrRequestExpired。
+// 按实际块高判断，不依赖出块时间的估算，出块变快时也能及时停止
+func (d *BlockDeadlines) WithBlockDeadline(ctx context.Context, deadlineBlock uint64) (context.Context, context.CancelFunc, error) {
+	currentBlock, err := d.backend.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if currentBlock >= deadlineBlock {
+		return nil, nil, ErrRequestExpired
+	}
+
+	ctxd, cancel := context.WithCancelCause(ctx)
+	w := &blockDeadline{block: deadlineBlock, cancel: cancel}
+
+	d.mu.Lock()
+	d.waiters[w] = struct{}{}
+	if !d.polling {
+		d.polling = true
+		go d.poll()
+	}
+	d.mu.Unlock()
+
+	context.AfterFunc(ctxd, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		delete(d.waiters, w)
+	})
+	return ctxd, func() { cancel(context.Canceled) }, nil
+}
+
+// poll 按 pollInterval 查询块高，取消已到达截止块高的 context，没有等待的请求时退出
+func (d *BlockDeadlines) poll() {
+	ticker := d.clock.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.Chan() {
+		d.mu.Lock()
+		if len(d.waiters) == 0 {
+			d.polling = false
+			d.mu.Unlock()
+			return
+		}
+		d.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), d.pollInterval)
+		height, err := d.backend.BlockNumber(ctx)
+		cancel()
+		if err != nil {
+			// 查询失败时保持等待，由下一轮或上层 context 决定何时结束
+			log.Warn("ContractsCaller unable to fetch block number for block deadlines", "err", err)
+			continue
+		}
+
+		d.mu.Lock()
+		for w := range d.waiters {
+			if height >= w.block {
+				delete(d.waiters, w)
+				w.cancel(ErrRequestExpired)
+			}
+		}
+		d.mu.Unlock()
+	}
+}
+
+// WithRequestScope 返回单个请求使用的 context：保留 request 的值和截止时间，
+// 同时在服务的根 context（shutdown）结束时取消，使请求相关的 goroutine 都能退出。
+// 调用方必须调用返回的 cancel 释放资源
+func WithRequestScope(root, request context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(request)
+	stop := context.AfterFunc(root, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,170 @@
+package txmgr_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestExpiryDeadline(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	deadline, err := txmgr.ExpiryDeadline(now, 100, 110, 2*time.Second)
+	require.Nil(t, err)
+	require.Equal(t, now.Add(20*time.Second), deadline)
+}
+
+func TestExpiryDeadlineExpired(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	_, err := txmgr.ExpiryDeadline(now, 110, 110, 2*time.Second)
+	require.Equal(t, txmgr.ErrRequestExpired, err)
+}
+
+func TestBlockhashExpiryBlock(t *testing.T) {
+	require.Equal(t, uint64(356), txmgr.BlockhashExpiryBlock(100))
+}
+
+func TestWithExpiryBlock(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	h.backend.mine(nil, nil)
+
+	ctx, cancel, err := txmgr.WithExpiryBlock(context.Background(), h.backend, 3, time.Second, nil)
+	require.Nil(t, err)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(2*time.Second), deadline, 500*time.Millisecond)
+
+	_, _, err = txmgr.WithExpiryBlock(context.Background(), h.backend, 1, time.Second, nil)
+	require.Equal(t, txmgr.ErrRequestExpired, err)
+}
+
+func TestWithExpiryBlockUsesClock(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	h.backend.mine(nil, nil)
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+
+	ctx, cancel, err := txmgr.WithExpiryBlock(context.Background(), h.backend, 3, time.Second, clock)
+	require.Nil(t, err)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, time.Unix(2, 0), deadline)
+
+	clock.Advance(time.Second)
+	require.Nil(t, ctx.Err())
+
+	clock.Advance(time.Second)
+	<-ctx.Done()
+	require.Equal(t, txmgr.ErrRequestExpired, context.Cause(ctx))
+}
+
+func TestTxMgrStopsAtExpiryBlock(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
+
+	ctx, cancel, err := txmgr.WithExpiryBlock(context.Background(), h.backend, 2, time.Second, nil)
+	require.Nil(t, err)
+	defer cancel()
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Equal(t, err, context.DeadlineExceeded)
+	require.Nil(t, result)
+	require.Equal(t, txmgr.ErrRequestExpired, context.Cause(ctx))
+}
+
+func TestBlockDeadlines(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	h.backend.mine(nil, nil)
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	deadlines := txmgr.NewBlockDeadlines(h.backend, time.Second, clock)
+
+	ctx, cancel, err := deadlines.WithBlockDeadline(context.Background(), 3)
+	require.Nil(t, err)
+	defer cancel()
+	later, cancelLater, err := deadlines.WithBlockDeadline(context.Background(), 4)
+	require.Nil(t, err)
+	defer cancelLater()
+
+	_, ok := ctx.Deadline()
+	require.False(t, ok)
+
+	clock.BlockUntil(1)
+	h.backend.mine(nil, nil)
+	clock.Advance(time.Second)
+	select {
+	case <-ctx.Done():
+		t.Fatal("context canceled before deadline block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	h.backend.mine(nil, nil)
+	clock.Advance(time.Second)
+	select {
+	case <-ctx.Done():
+		require.Equal(t, context.Canceled, ctx.Err())
+		require.Equal(t, txmgr.ErrRequestExpired, context.Cause(ctx))
+	case <-time.After(time.Second):
+		t.Fatal("context not canceled at deadline block")
+	}
+	require.Nil(t, later.Err())
+
+	cancelLater()
+	require.Equal(t, context.Canceled, context.Cause(later))
+
+	_, _, err = deadlines.WithBlockDeadline(context.Background(), 3)
+	require.Equal(t, txmgr.ErrRequestExpired, err)
+}
+
+func TestWithRequestScope(t *testing.T) {
+	t.Parallel()
+
+	type key struct{}
+	root, shutdown := context.WithCancel(context.Background())
+	request, cancelRequest := context.WithTimeout(
+		context.WithValue(context.Background(), key{}, "req"), time.Hour)
+	defer cancelRequest()
+
+	ctx, cancel := txmgr.WithRequestScope(root, request)
+	defer cancel()
+
+	require.Equal(t, "req", ctx.Value(key{}))
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Second)
+
+	shutdown()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("request scope not canceled on shutdown")
+	}
+}
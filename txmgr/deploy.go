@@ -0,0 +1,62 @@
+package txmgr
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+	"math/big"
+)
+
+var (
+	// ErrNotDeployment 部署交易的 To 必须为空
+	ErrNotDeployment = errors.New("txmgr: deployment transaction must not have a recipient")
+	// ErrDeploymentReverted 部署交易执行失败
+	ErrDeploymentReverted = errors.New("txmgr: deployment transaction reverted")
+	// ErrNoContractCode 部署确认后目标地址没有合约代码
+	ErrNoContractCode = errors.New("txmgr: no code at deployed contract address")
+)
+
+// CodeSource 查询地址上的合约代码
+type CodeSource interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// DeployContract 通过 Send 发送合约部署交易，确认后校验部署地址上存在合约代码，返回部署地址和回执
+func DeployContract(
+	ctx context.Context,
+	mgr TxManager,
+	backend CodeSource,
+	updateGasPrice UpdateGasPriceFunc,
+	sendTx SendTransactionFunc,
+) (common.Address, *types.Receipt, error) {
+	updateDeployGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		tx, err := updateGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if tx.To() != nil {
+			return nil, ErrNotDeployment
+		}
+		return tx, nil
+	}
+
+	result, err := mgr.Send(ctx, updateDeployGasPrice, sendTx)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	receipt := result.Receipt
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, receipt, ErrDeploymentReverted
+	}
+
+	code, err := backend.CodeAt(ctx, receipt.ContractAddress, receipt.BlockNumber)
+	if err != nil {
+		return common.Address{}, receipt, err
+	}
+	if len(code) == 0 {
+		return common.Address{}, receipt, ErrNoContractCode
+	}
+
+	return receipt.ContractAddress, receipt, nil
+}
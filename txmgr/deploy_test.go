@@ -0,0 +1,123 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var testDeployer = common.HexToAddress("0xdeadbeef")
+
+type deployBackend struct {
+	*mockBackend
+
+	status uint64
+	code   []byte
+}
+
+func (b *deployBackend) TransactionReceipt(
+	ctx context.Context,
+	txHash common.Hash,
+) (*types.Receipt, error) {
+
+	receipt, err := b.mockBackend.TransactionReceipt(ctx, txHash)
+	if receipt == nil || err != nil {
+		return receipt, err
+	}
+	receipt.Status = b.status
+	receipt.ContractAddress = crypto.CreateAddress(testDeployer, 0)
+	return receipt, nil
+}
+
+func (b *deployBackend) CodeAt(
+	ctx context.Context,
+	contract common.Address,
+	blockNumber *big.Int,
+) ([]byte, error) {
+
+	return b.code, nil
+}
+
+func deployContract(t *testing.T, backend *deployBackend, to *common.Address) (common.Address, error) {
+	mgr := newTxManager(t, configWithNumConfs(1), backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			To:        to,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addr, _, err := txmgr.DeployContract(ctx, mgr, backend, updateGasPrice, sendTx)
+	return addr, err
+}
+
+func TestDeployContract(t *testing.T) {
+	t.Parallel()
+
+	backend := &deployBackend{
+		mockBackend: newMockBackend(),
+		status:      types.ReceiptStatusSuccessful,
+		code:        []byte{0x60, 0x80},
+	}
+
+	addr, err := deployContract(t, backend, nil)
+	require.Nil(t, err)
+	require.Equal(t, crypto.CreateAddress(testDeployer, 0), addr)
+}
+
+func TestDeployContractRejectsRecipient(t *testing.T) {
+	t.Parallel()
+
+	backend := &deployBackend{
+		mockBackend: newMockBackend(),
+		status:      types.ReceiptStatusSuccessful,
+		code:        []byte{0x60, 0x80},
+	}
+
+	to := common.HexToAddress("0x01")
+	_, err := deployContract(t, backend, &to)
+	require.ErrorIs(t, err, txmgr.ErrNotDeployment)
+}
+
+func TestDeployContractReverted(t *testing.T) {
+	t.Parallel()
+
+	backend := &deployBackend{
+		mockBackend: newMockBackend(),
+		status:      types.ReceiptStatusFailed,
+	}
+
+	_, err := deployContract(t, backend, nil)
+	require.ErrorIs(t, err, txmgr.ErrDeploymentReverted)
+}
+
+func TestDeployContractWithoutCode(t *testing.T) {
+	t.Parallel()
+
+	backend := &deployBackend{
+		mockBackend: newMockBackend(),
+		status:      types.ReceiptStatusSuccessful,
+	}
+
+	_, err := deployContract(t, backend, nil)
+	require.ErrorIs(t, err, txmgr.ErrNoContractCode)
+}
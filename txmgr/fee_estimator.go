@@ -0,0 +1,52 @@
+package txmgr
+
+import (
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+	"math/big"
+)
+
+// FeeEstimator 提供下一个块的 baseFee 以及最近块中给定分位的小费
+type FeeEstimator interface {
+	CurrentFees(ctx context.Context, percentile float64) (baseFee *big.Int, gasTipCap *big.Int, err error)
+}
+
+// FeeHistoryReader 支持 eth_feeHistory 的 backend，*ethclient.Client 实现了该接口
+type FeeHistoryReader interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// ErrEmptyFeeHistory eth_feeHistory 返回的数据为空
+var ErrEmptyFeeHistory = errors.New("txmgr: empty fee history")
+
+type feeHistoryEstimator struct {
+	backend FeeHistoryReader
+}
+
+// NewFeeHistoryEstimator 基于最新一个块的 eth_feeHistory 估算费用
+func NewFeeHistoryEstimator(backend FeeHistoryReader) FeeEstimator {
+	return &feeHistoryEstimator{backend: backend}
+}
+
+func (e *feeHistoryEstimator) CurrentFees(ctx context.Context, percentile float64) (*big.Int, *big.Int, error) {
+	history, err := e.backend.FeeHistory(ctx, 1, nil, []float64{percentile})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(history.BaseFee) == 0 || len(history.Reward) == 0 || len(history.Reward[0]) == 0 {
+		return nil, nil, ErrEmptyFeeHistory
+	}
+	// BaseFee 的最后一项是下一个块的 baseFee
+	return history.BaseFee[len(history.BaseFee)-1], history.Reward[0][0], nil
+}
+
+// IsFeeCompetitive 交易的小费不低于当前分位小费，且 feeCap 能覆盖 baseFee 加该小费
+func IsFeeCompetitive(tx *types.Transaction, baseFee, gasTipCap *big.Int) bool {
+	if tx.GasTipCap().Cmp(gasTipCap) < 0 {
+		return false
+	}
+	required := new(big.Int).Add(baseFee, gasTipCap)
+	return tx.GasFeeCap().Cmp(required) >= 0
+}
@@ -0,0 +1,253 @@
+package txmgr_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type staticFeeEstimator struct {
+	baseFee   *big.Int
+	gasTipCap *big.Int
+}
+
+func (e *staticFeeEstimator) CurrentFees(ctx context.Context, percentile float64) (*big.Int, *big.Int, error) {
+	return e.baseFee, e.gasTipCap, nil
+}
+
+type feeHistoryBackend struct {
+	history *ethereum.FeeHistory
+}
+
+func (b *feeHistoryBackend) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+
+	return b.history, nil
+}
+
+func TestIsFeeCompetitive(t *testing.T) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		GasTipCap: big.NewInt(5),
+		GasFeeCap: big.NewInt(20),
+	})
+
+	require.True(t, txmgr.IsFeeCompetitive(tx, big.NewInt(15), big.NewInt(5)))
+	require.False(t, txmgr.IsFeeCompetitive(tx, big.NewInt(16), big.NewInt(5)))
+	require.False(t, txmgr.IsFeeCompetitive(tx, big.NewInt(1), big.NewInt(6)))
+}
+
+func TestFeeHistoryEstimator(t *testing.T) {
+	estimator := txmgr.NewFeeHistoryEstimator(&feeHistoryBackend{
+		history: &ethereum.FeeHistory{
+			Reward:  [][]*big.Int{{big.NewInt(3)}},
+			BaseFee: []*big.Int{big.NewInt(10), big.NewInt(11)},
+		},
+	})
+
+	baseFee, gasTipCap, err := estimator.CurrentFees(context.Background(), 60)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(11), baseFee)
+	require.Equal(t, big.NewInt(3), gasTipCap)
+
+	estimator = txmgr.NewFeeHistoryEstimator(&feeHistoryBackend{
+		history: &ethereum.FeeHistory{},
+	})
+	_, _, err = estimator.CurrentFees(context.Background(), 60)
+	require.Equal(t, txmgr.ErrEmptyFeeHistory, err)
+}
+
+func TestTxMgrSkipsBumpWhileFeesCompetitive(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.BumpSkipPercentile = 60
+	cfg.FeeEstimator = &staticFeeEstimator{
+		baseFee:   big.NewInt(7),
+		gasTipCap: big.NewInt(5),
+	}
+	h := newTestHarnessWithConfig(t, cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sender := txmgrtest.NewFakeSender(nil, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+
+	// The bumps at 1s and 2s are skipped, rebroadcasting the original
+	// transaction instead.
+	result, err := h.mgr.Send(ctx, updateGasPrice, sender.Send)
+	require.Equal(t, err, context.DeadlineExceeded)
+	require.Nil(t, result)
+	sent := sender.Sent()
+	require.Len(t, sent, 3)
+	for _, tx := range sent {
+		require.Equal(t, sent[0].Hash(), tx.Hash())
+	}
+}
+
+func TestTxMgrRebroadcastsDroppedTxWhileFeesCompetitive(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.BumpSkipPercentile = 60
+	cfg.FeeEstimator = &staticFeeEstimator{
+		baseFee:   big.NewInt(7),
+		gasTipCap: big.NewInt(5),
+	}
+	h := newTestHarnessWithConfig(t, cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	var (
+		mu     sync.Mutex
+		hashes []common.Hash
+	)
+	mineTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+	// The first broadcast is dropped by the mempool, so only the
+	// rebroadcast can get the transaction mined.
+	dropTx := txmgrtest.NewInjector(txmgrtest.Faults{DropTx: 1}).WrapSend(mineTx)
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		mu.Lock()
+		hashes = append(hashes, tx.Hash())
+		first := len(hashes) == 1
+		mu.Unlock()
+
+		if first {
+			return dropTx(ctx, tx)
+		}
+		return mineTx(ctx, tx)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.Len(t, hashes, 2)
+	require.Equal(t, hashes[0], hashes[1])
+	require.Equal(t, hashes[0], result.Receipt.TxHash)
+	require.Equal(t, uint64(1), result.Attempts)
+}
+
+func TestTxMgrBumpsWhenFeesFallBehind(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.BumpSkipPercentile = 60
+	cfg.FeeEstimator = &staticFeeEstimator{
+		baseFee:   big.NewInt(7),
+		gasTipCap: big.NewInt(100),
+	}
+	h := newTestHarnessWithConfig(t, cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if h.gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	result, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
+}
+
+// deadlineFeeEstimator 记录查询费用时 ctx 的截止时间，并返回查询失败
+type deadlineFeeEstimator struct {
+	mu        sync.Mutex
+	deadlines []time.Time
+}
+
+func (e *deadlineFeeEstimator) CurrentFees(ctx context.Context, percentile float64) (*big.Int, *big.Int, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, nil, errors.New("no deadline")
+	}
+	e.mu.Lock()
+	e.deadlines = append(e.deadlines, deadline)
+	e.mu.Unlock()
+	return nil, nil, errors.New("node too slow")
+}
+
+func TestTxMgrBoundsFeeQueryDuration(t *testing.T) {
+	t.Parallel()
+
+	estimator := &deadlineFeeEstimator{}
+	cfg := configWithNumConfs(1)
+	cfg.BumpSkipPercentile = 60
+	cfg.FeeEstimator = estimator
+	h := newTestHarnessWithConfig(t, cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if h.gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	// Send 本身没有截止时间，查询费用时仍应带上超时
+	start := time.Now()
+	result, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+
+	estimator.mu.Lock()
+	defer estimator.mu.Unlock()
+	require.NotEmpty(t, estimator.deadlines)
+	for _, deadline := range estimator.deadlines {
+		require.True(t, deadline.Before(time.Now().Add(10*time.Second)))
+		require.True(t, deadline.After(start))
+	}
+}
@@ -0,0 +1,245 @@
+package txmgr
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/net/context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxFeeHistoryPercentiles 一次 eth_feeHistory 查询最多的小费分位数，geth 拒绝更多分位的请求
+const MaxFeeHistoryPercentiles = 100
+
+// ErrTooManyPercentiles FeeState 跟踪的小费分位已达 MaxFeeHistoryPercentiles，无法再加入新的分位
+var ErrTooManyPercentiles = fmt.Errorf("%w: too many fee history percentiles", ErrInvalidConfig)
+
+// FeeStateConfig FeeState 的配置。BlockCount、RefreshInterval 和 MaxAge 必须大于 0，
+// Percentiles 不超过 MaxFeeHistoryPercentiles 个且都在 [0, 100] 内
+type FeeStateConfig struct {
+	BlockCount      uint64        // 每次 eth_feeHistory 查询的块数，小费取这些块在对应分位上的中位数
+	Percentiles     []float64     // 预先查询的小费分位，CurrentFees 请求其他分位时自动加入，总数不超过 MaxFeeHistoryPercentiles
+	RefreshInterval time.Duration // Run 后台刷新的时间间隔
+	MaxAge          time.Duration // 缓存超过该时长后 CurrentFees 会先刷新再返回
+	Clock           Clock         // 时间来源，为空时使用 SystemClock
+}
+
+// validate 检查配置是否合法
+func (cfg FeeStateConfig) validate() error {
+	switch {
+	case cfg.BlockCount == 0:
+		return fmt.Errorf("%w: BlockCount must be > 0", ErrInvalidConfig)
+	case cfg.RefreshInterval <= 0:
+		return fmt.Errorf("%w: RefreshInterval must be > 0", ErrInvalidConfig)
+	case cfg.MaxAge <= 0:
+		return fmt.Errorf("%w: MaxAge must be > 0", ErrInvalidConfig)
+	case len(cfg.Percentiles) > MaxFeeHistoryPercentiles:
+		return fmt.Errorf("%w: %d percentiles, want at most %d", ErrTooManyPercentiles, len(cfg.Percentiles), MaxFeeHistoryPercentiles)
+	}
+	for _, p := range cfg.Percentiles {
+		if err := validatePercentile(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePercentile(p float64) error {
+	if p < 0 || p > 100 {
+		return fmt.Errorf("%w: percentile %v must be within [0, 100]", ErrInvalidConfig, p)
+	}
+	return nil
+}
+
+// FeeSnapshot 某一时刻的费用数据
+type FeeSnapshot struct {
+	BaseFee   *big.Int             // 下一个块的 baseFee
+	Tips      map[float64]*big.Int // 各分位的小费
+	UpdatedAt time.Time
+}
+
+// FeeState 维护最近的 baseFee 和小费分位，供 txmgr、收益检查和熔断器等模块共享，
+// 避免各模块重复查询 eth_feeHistory。实现了 FeeEstimator，可直接设置到 Config.FeeEstimator
+type FeeState struct {
+	backend FeeHistoryReader
+	cfg     FeeStateConfig
+
+	// refreshMu 串行化刷新，并发调用方复用同一次查询结果
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	percentiles []float64
+	snapshot    *FeeSnapshot
+}
+
+var _ FeeEstimator = (*FeeState)(nil)
+
+func NewFeeState(backend FeeHistoryReader, cfg FeeStateConfig) (*FeeState, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	return &FeeState{
+		backend:     backend,
+		cfg:         cfg,
+		percentiles: append([]float64(nil), cfg.Percentiles...),
+	}, nil
+}
+
+// Snapshot 返回最近一次刷新的费用数据的副本，尚未刷新过时返回 false
+func (s *FeeState) Snapshot() (FeeSnapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.snapshot == nil {
+		return FeeSnapshot{}, false
+	}
+	tips := make(map[float64]*big.Int, len(s.snapshot.Tips))
+	for p, tip := range s.snapshot.Tips {
+		tips[p] = new(big.Int).Set(tip)
+	}
+	return FeeSnapshot{
+		BaseFee:   new(big.Int).Set(s.snapshot.BaseFee),
+		Tips:      tips,
+		UpdatedAt: s.snapshot.UpdatedAt,
+	}, true
+}
+
+// CurrentFees 返回缓存的费用，缓存过期或没有该分位时先刷新。新的分位会被加入之后的每次刷新，
+// 已跟踪 MaxFeeHistoryPercentiles 个分位时请求新分位返回 ErrTooManyPercentiles
+func (s *FeeState) CurrentFees(ctx context.Context, percentile float64) (*big.Int, *big.Int, error) {
+	if baseFee, tip, ok := s.cached(percentile); ok {
+		return baseFee, tip, nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	// 等锁期间其他调用方可能已经刷新
+	if baseFee, tip, ok := s.cached(percentile); ok {
+		return baseFee, tip, nil
+	}
+	if err := s.addPercentile(percentile); err != nil {
+		return nil, nil, err
+	}
+	if err := s.refreshLocked(ctx); err != nil {
+		return nil, nil, err
+	}
+	baseFee, tip, _ := s.cached(percentile)
+	return baseFee, tip, nil
+}
+
+// Refresh 立即查询一次 eth_feeHistory，可在收到新块时调用
+func (s *FeeState) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	return s.refreshLocked(ctx)
+}
+
+// Run 按 RefreshInterval 刷新费用数据，直到 ctx 结束
+func (s *FeeState) Run(ctx context.Context) {
+	ticker := s.cfg.Clock.NewTicker(s.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Warn("ContractsCaller unable to refresh fee state", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
+
+func (s *FeeState) cached(percentile float64) (*big.Int, *big.Int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.snapshot == nil || s.cfg.Clock.Now().Sub(s.snapshot.UpdatedAt) >= s.cfg.MaxAge {
+		return nil, nil, false
+	}
+	tip, ok := s.snapshot.Tips[percentile]
+	if !ok {
+		return nil, nil, false
+	}
+	// 返回副本，调用方修改结果不影响共享的缓存
+	return new(big.Int).Set(s.snapshot.BaseFee), new(big.Int).Set(tip), true
+}
+
+func (s *FeeState) addPercentile(percentile float64) error {
+	if err := validatePercentile(percentile); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.percentiles {
+		if p == percentile {
+			return nil
+		}
+	}
+	if len(s.percentiles) >= MaxFeeHistoryPercentiles {
+		return fmt.Errorf("%w: cannot add percentile %v", ErrTooManyPercentiles, percentile)
+	}
+	s.percentiles = append(s.percentiles, percentile)
+	// eth_feeHistory 要求分位单调递增
+	sort.Float64s(s.percentiles)
+	return nil
+}
+
+func (s *FeeState) refreshLocked(ctx context.Context) error {
+	s.mu.RLock()
+	percentiles := append([]float64(nil), s.percentiles...)
+	s.mu.RUnlock()
+
+	history, err := s.backend.FeeHistory(ctx, s.cfg.BlockCount, nil, percentiles)
+	if err != nil {
+		return err
+	}
+	if len(history.BaseFee) == 0 {
+		return ErrEmptyFeeHistory
+	}
+
+	tips := make(map[float64]*big.Int, len(percentiles))
+	for i, p := range percentiles {
+		rewards := make([]*big.Int, 0, len(history.Reward))
+		for _, blockRewards := range history.Reward {
+			if i < len(blockRewards) && blockRewards[i] != nil {
+				rewards = append(rewards, blockRewards[i])
+			}
+		}
+		if len(rewards) == 0 {
+			return ErrEmptyFeeHistory
+		}
+		tips[p] = medianBig(rewards)
+	}
+
+	snapshot := &FeeSnapshot{
+		// BaseFee 的最后一项是下一个块的 baseFee
+		BaseFee:   history.BaseFee[len(history.BaseFee)-1],
+		Tips:      tips,
+		UpdatedAt: s.cfg.Clock.Now(),
+	}
+
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.mu.Unlock()
+	return nil
+}
+
+// medianBig 返回中位数，偶数个时取较小的一个
+func medianBig(values []*big.Int) *big.Int {
+	sorted := append([]*big.Int(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	return sorted[(len(sorted)-1)/2]
+}
@@ -0,0 +1,190 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum"
+)
+
+// countingFeeHistoryBackend 记录查询次数和最近一次查询的分位
+type countingFeeHistoryBackend struct {
+	calls       atomic.Int32
+	percentiles []float64
+}
+
+func (b *countingFeeHistoryBackend) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+
+	b.calls.Add(1)
+	b.percentiles = rewardPercentiles
+
+	// 每个分位在三个块中的小费分别为 p、p+2、p+1
+	reward := make([][]*big.Int, 3)
+	for i, offset := range []int64{0, 2, 1} {
+		for _, p := range rewardPercentiles {
+			reward[i] = append(reward[i], big.NewInt(int64(p)+offset))
+		}
+	}
+	return &ethereum.FeeHistory{
+		Reward:  reward,
+		BaseFee: []*big.Int{big.NewInt(10), big.NewInt(11), big.NewInt(12), big.NewInt(13)},
+	}, nil
+}
+
+func newFeeState(t *testing.T, backend txmgr.FeeHistoryReader) (*txmgr.FeeState, *txmgrtest.FakeClock) {
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	state, err := txmgr.NewFeeState(backend, txmgr.FeeStateConfig{
+		BlockCount:      3,
+		Percentiles:     []float64{50},
+		RefreshInterval: time.Second,
+		MaxAge:          5 * time.Second,
+		Clock:           clock,
+	})
+	require.Nil(t, err)
+	return state, clock
+}
+
+func TestFeeStateCachesFees(t *testing.T) {
+	t.Parallel()
+
+	backend := &countingFeeHistoryBackend{}
+	state, clock := newFeeState(t, backend)
+
+	_, ok := state.Snapshot()
+	require.False(t, ok)
+
+	baseFee, gasTipCap, err := state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(13), baseFee)
+	require.Equal(t, big.NewInt(51), gasTipCap)
+
+	_, _, err = state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+	require.Equal(t, int32(1), backend.calls.Load())
+
+	clock.Advance(5 * time.Second)
+	_, _, err = state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+	require.Equal(t, int32(2), backend.calls.Load())
+}
+
+func TestFeeStateAddsPercentiles(t *testing.T) {
+	t.Parallel()
+
+	backend := &countingFeeHistoryBackend{}
+	state, _ := newFeeState(t, backend)
+
+	_, gasTipCap, err := state.CurrentFees(context.Background(), 20)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(21), gasTipCap)
+	require.Equal(t, []float64{20, 50}, backend.percentiles)
+
+	_, gasTipCap, err = state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(51), gasTipCap)
+	require.Equal(t, int32(1), backend.calls.Load())
+}
+
+func TestFeeStateLimitsPercentiles(t *testing.T) {
+	t.Parallel()
+
+	backend := &countingFeeHistoryBackend{}
+	state, _ := newFeeState(t, backend)
+
+	// 已有 50 分位，再加入 99 个分位达到上限
+	for i := 0; i < txmgr.MaxFeeHistoryPercentiles-1; i++ {
+		_, _, err := state.CurrentFees(context.Background(), float64(i)/2)
+		require.Nil(t, err)
+	}
+	require.Len(t, backend.percentiles, txmgr.MaxFeeHistoryPercentiles)
+
+	_, _, err := state.CurrentFees(context.Background(), 99.5)
+	require.ErrorIs(t, err, txmgr.ErrTooManyPercentiles)
+	require.Len(t, backend.percentiles, txmgr.MaxFeeHistoryPercentiles)
+
+	// 已跟踪的分位不受影响
+	_, _, err = state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+
+	_, _, err = state.CurrentFees(context.Background(), 101)
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
+
+func TestFeeStateConfigRejectsTooManyPercentiles(t *testing.T) {
+	t.Parallel()
+
+	percentiles := make([]float64, txmgr.MaxFeeHistoryPercentiles+1)
+	for i := range percentiles {
+		percentiles[i] = float64(i) / 2
+	}
+	_, err := txmgr.NewFeeState(&countingFeeHistoryBackend{}, txmgr.FeeStateConfig{
+		BlockCount:      3,
+		Percentiles:     percentiles,
+		RefreshInterval: time.Second,
+		MaxAge:          5 * time.Second,
+	})
+	require.ErrorIs(t, err, txmgr.ErrTooManyPercentiles)
+}
+
+func TestFeeStateRun(t *testing.T) {
+	t.Parallel()
+
+	backend := &countingFeeHistoryBackend{}
+	state, clock := newFeeState(t, backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		state.Run(ctx)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool {
+		return backend.calls.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	snapshot, ok := state.Snapshot()
+	require.True(t, ok)
+	require.Equal(t, big.NewInt(51), snapshot.Tips[50])
+
+	cancel()
+	<-done
+}
+
+func TestFeeStateReturnsCopies(t *testing.T) {
+	t.Parallel()
+
+	state, _ := newFeeState(t, &countingFeeHistoryBackend{})
+
+	baseFee, gasTipCap, err := state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+	baseFee.SetInt64(0)
+	gasTipCap.SetInt64(0)
+
+	snapshot, ok := state.Snapshot()
+	require.True(t, ok)
+	snapshot.BaseFee.SetInt64(0)
+	snapshot.Tips[50].SetInt64(0)
+	snapshot.Tips[20] = big.NewInt(0)
+
+	baseFee, gasTipCap, err = state.CurrentFees(context.Background(), 50)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(13), baseFee)
+	require.Equal(t, big.NewInt(51), gasTipCap)
+	snapshot, _ = state.Snapshot()
+	require.Len(t, snapshot.Tips, 1)
+}
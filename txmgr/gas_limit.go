@@ -0,0 +1,35 @@
+package txmgr
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GasLimitPadding 估算 gas 之后追加的余量，回调合约的 gas 消耗在估算和打包之间可能变化
+type GasLimitPadding struct {
+	Percent uint64 // 按估算值百分比追加
+	Floor   uint64 // 追加量的下限
+}
+
+// Pad 返回追加余量后的 gas limit
+func (p GasLimitPadding) Pad(estimate uint64) uint64 {
+	pad := estimate / 100 * p.Percent
+	pad += estimate % 100 * p.Percent / 100
+	if pad < p.Floor {
+		pad = p.Floor
+	}
+	return estimate + pad
+}
+
+// GasLimitPolicy 默认的余量配置，以及按目标合约（如不同的 coordinator）覆盖的配置
+type GasLimitPolicy struct {
+	Default   GasLimitPadding
+	Overrides map[common.Address]GasLimitPadding
+}
+
+// PadFor 按目标合约地址选择余量配置并返回追加后的 gas limit
+func (p GasLimitPolicy) PadFor(to common.Address, estimate uint64) uint64 {
+	if padding, ok := p.Overrides[to]; ok {
+		return padding.Pad(estimate)
+	}
+	return p.Default.Pad(estimate)
+}
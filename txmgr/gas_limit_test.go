@@ -0,0 +1,36 @@
+package txmgr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestGasLimitPaddingPercent(t *testing.T) {
+	padding := txmgr.GasLimitPadding{Percent: 20}
+	require.Equal(t, uint64(120_000), padding.Pad(100_000))
+	require.Equal(t, uint64(150), padding.Pad(125))
+}
+
+func TestGasLimitPaddingFloor(t *testing.T) {
+	padding := txmgr.GasLimitPadding{Percent: 10, Floor: 50_000}
+	require.Equal(t, uint64(150_000), padding.Pad(100_000))
+	require.Equal(t, uint64(1_100_000), padding.Pad(1_000_000))
+}
+
+func TestGasLimitPolicyOverrides(t *testing.T) {
+	coordinator := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+
+	policy := txmgr.GasLimitPolicy{
+		Default: txmgr.GasLimitPadding{Percent: 10},
+		Overrides: map[common.Address]txmgr.GasLimitPadding{
+			coordinator: {Percent: 50},
+		},
+	}
+	require.Equal(t, uint64(150_000), policy.PadFor(coordinator, 100_000))
+	require.Equal(t, uint64(110_000), policy.PadFor(other, 100_000))
+}
@@ -0,0 +1,148 @@
+package txmgr
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/net/context"
+	"math/big"
+	"sync/atomic"
+	"time"
+)
+
+// gasSpikePercentile 查询费用时使用的小费分位，GasSpikeGuard 只关心 baseFee
+const gasSpikePercentile = 50
+
+// GasSpikeConfig GasSpikeGuard 的配置
+type GasSpikeConfig struct {
+	BaseFeeCeiling *big.Int      // baseFee 高于该值时暂缓非紧急的 Send
+	FeeEstimator   FeeEstimator  // baseFee 来源，通常与 Config.FeeEstimator 共用同一个 FeeState
+	PollInterval   time.Duration // 暂缓期间重新检查 baseFee 的时间间隔
+	ExpiryMargin   time.Duration // ctx 的截止时间在该时长内时不再等待，0 表示等到截止时间
+	Clock          Clock         // 时间来源，为空时使用 SystemClock
+}
+
+// validate 检查配置是否合法
+func (cfg GasSpikeConfig) validate() error {
+	switch {
+	case cfg.BaseFeeCeiling == nil || cfg.BaseFeeCeiling.Sign() <= 0:
+		return fmt.Errorf("%w: BaseFeeCeiling must be > 0", ErrInvalidConfig)
+	case cfg.FeeEstimator == nil:
+		return fmt.Errorf("%w: FeeEstimator is required", ErrInvalidConfig)
+	case cfg.PollInterval <= 0:
+		return fmt.Errorf("%w: PollInterval must be > 0", ErrInvalidConfig)
+	case cfg.ExpiryMargin < 0:
+		return fmt.Errorf("%w: ExpiryMargin must be >= 0", ErrInvalidConfig)
+	}
+	return nil
+}
+
+type premiumKey struct{}
+
+// WithPremium 标记本次 Send 为高优先级（例如付费加急的请求），GasSpikeGuard 不暂缓该 Send
+func WithPremium(ctx context.Context) context.Context {
+	return context.WithValue(ctx, premiumKey{}, true)
+}
+
+// isPremium ctx 是否由 WithPremium 标记
+func isPremium(ctx context.Context) bool {
+	premium, _ := ctx.Value(premiumKey{}).(bool)
+	return premium
+}
+
+// GasSpikeGuard 包装 TxManager，baseFee 超过上限时暂缓非紧急的 Send，费用回落后继续发送。
+// WithPremium 标记的 Send 直接放行；ctx 的截止时间（如 WithExpiryBlock 得到的请求过期时间）
+// 进入 ExpiryMargin 后也直接放行，避免请求在等待中过期
+type GasSpikeGuard struct {
+	next   TxManager
+	cfg    GasSpikeConfig
+	queued atomic.Int64
+}
+
+func NewGasSpikeGuard(next TxManager, cfg GasSpikeConfig) (*GasSpikeGuard, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	cfg.BaseFeeCeiling = new(big.Int).Set(cfg.BaseFeeCeiling)
+	return &GasSpikeGuard{next: next, cfg: cfg}, nil
+}
+
+// Queued 返回正在等待 baseFee 回落的 Send 数量
+func (g *GasSpikeGuard) Queued() int64 {
+	return g.queued.Load()
+}
+
+func (g *GasSpikeGuard) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*SendResult, error) {
+	if err := g.wait(ctx); err != nil {
+		return nil, err
+	}
+	return g.next.Send(ctx, updateGasPrice, sendTx)
+}
+
+// wait 在 baseFee 超过上限时等待，直到费用回落、请求临近过期或 ctx 结束
+func (g *GasSpikeGuard) wait(ctx context.Context) error {
+	if isPremium(ctx) {
+		return nil
+	}
+
+	queued := false
+	defer func() {
+		if queued {
+			g.queued.Add(-1)
+		}
+	}()
+
+	for {
+		baseFee, _, err := g.cfg.FeeEstimator.CurrentFees(ctx, gasSpikePercentile)
+		if err != nil {
+			// 查询失败时不阻塞发送，由 txmgr 自身的加价逻辑处理
+			log.Warn("ContractsCaller unable to fetch base fee, skipping gas spike check", "err", err)
+			return nil
+		}
+		if baseFee.Cmp(g.cfg.BaseFeeCeiling) <= 0 {
+			if queued {
+				log.Info("ContractsCaller base fee back under ceiling, resuming send", "baseFee", baseFee)
+			}
+			return nil
+		}
+		if g.nearExpiry(ctx) {
+			log.Warn("ContractsCaller request close to expiry, sending despite gas spike",
+				"baseFee", baseFee, "ceiling", g.cfg.BaseFeeCeiling)
+			return nil
+		}
+		if !queued {
+			queued = true
+			g.queued.Add(1)
+			log.Info("ContractsCaller base fee above ceiling, holding send",
+				"baseFee", baseFee, "ceiling", g.cfg.BaseFeeCeiling)
+		}
+
+		select {
+		case <-g.cfg.Clock.After(g.pollDelay(ctx)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pollDelay 下一次检查前等待的时长，不晚于截止时间进入 ExpiryMargin 的时刻
+func (g *GasSpikeGuard) pollDelay(ctx context.Context) time.Duration {
+	delay := g.cfg.PollInterval
+	if deadline, ok := ctx.Deadline(); ok && g.cfg.ExpiryMargin > 0 {
+		if untilMargin := deadline.Sub(g.cfg.Clock.Now()) - g.cfg.ExpiryMargin; untilMargin < delay {
+			delay = untilMargin
+		}
+	}
+	return delay
+}
+
+// nearExpiry ctx 的截止时间是否已进入 ExpiryMargin
+func (g *GasSpikeGuard) nearExpiry(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return false
+	}
+	return deadline.Sub(g.cfg.Clock.Now()) <= g.cfg.ExpiryMargin
+}
@@ -0,0 +1,157 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// baseFeeEstimator 返回可由测试修改的 baseFee
+type baseFeeEstimator struct {
+	mu      sync.Mutex
+	baseFee *big.Int
+}
+
+func (e *baseFeeEstimator) set(baseFee int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.baseFee = big.NewInt(baseFee)
+}
+
+func (e *baseFeeEstimator) CurrentFees(ctx context.Context, percentile float64) (*big.Int, *big.Int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.baseFee, big.NewInt(1), nil
+}
+
+func newGasSpikeGuard(t *testing.T, baseFee int64) (*txmgr.GasSpikeGuard, *stubTxManager, *baseFeeEstimator, *txmgrtest.FakeClock) {
+	next := &stubTxManager{status: types.ReceiptStatusSuccessful}
+	estimator := &baseFeeEstimator{baseFee: big.NewInt(baseFee)}
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	guard, err := txmgr.NewGasSpikeGuard(next, txmgr.GasSpikeConfig{
+		BaseFeeCeiling: big.NewInt(100),
+		FeeEstimator:   estimator,
+		PollInterval:   time.Minute,
+		ExpiryMargin:   time.Minute,
+		Clock:          clock,
+	})
+	require.Nil(t, err)
+	return guard, next, estimator, clock
+}
+
+func TestGasSpikeGuardSendsUnderCeiling(t *testing.T) {
+	t.Parallel()
+
+	guard, next, _, _ := newGasSpikeGuard(t, 100)
+
+	_, err := guard.Send(context.Background(), nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, next.calls)
+}
+
+func TestGasSpikeGuardHoldsUntilFeesNormalize(t *testing.T) {
+	t.Parallel()
+
+	guard, next, estimator, clock := newGasSpikeGuard(t, 101)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := guard.Send(context.Background(), nil, nil)
+		errc <- err
+	}()
+
+	// 仍在上限之上，继续等待
+	clock.BlockUntil(1)
+	require.Equal(t, int64(1), guard.Queued())
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+
+	estimator.set(90)
+	clock.Advance(time.Minute)
+	require.Nil(t, <-errc)
+	require.Equal(t, 1, next.calls)
+	require.Equal(t, int64(0), guard.Queued())
+}
+
+func TestGasSpikeGuardAdmitsPremiumSends(t *testing.T) {
+	t.Parallel()
+
+	guard, next, _, _ := newGasSpikeGuard(t, 1000)
+
+	_, err := guard.Send(txmgr.WithPremium(context.Background()), nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, next.calls)
+}
+
+func TestGasSpikeGuardAdmitsSendsNearExpiry(t *testing.T) {
+	t.Parallel()
+
+	guard, next, _, clock := newGasSpikeGuard(t, 1000)
+
+	ctx, cancel := txmgr.WithClockDeadline(context.Background(), clock, clock.Now().Add(30*time.Second), txmgr.ErrRequestExpired)
+	defer cancel()
+
+	_, err := guard.Send(ctx, nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, next.calls)
+}
+
+func TestGasSpikeGuardReleasesHeldSendAtExpiryMargin(t *testing.T) {
+	t.Parallel()
+
+	guard, next, _, clock := newGasSpikeGuard(t, 1000)
+
+	ctx, cancel := txmgr.WithClockDeadline(context.Background(), clock, clock.Now().Add(90*time.Second), txmgr.ErrRequestExpired)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := guard.Send(ctx, nil, nil)
+		errc <- err
+	}()
+
+	// 截止时间的定时器加上 guard 的轮询定时器，轮询在截止时间进入 ExpiryMargin 时触发
+	clock.BlockUntil(2)
+	require.Equal(t, int64(1), guard.Queued())
+	clock.Advance(30 * time.Second)
+	require.Nil(t, <-errc)
+	require.Equal(t, 1, next.calls)
+}
+
+func TestGasSpikeGuardHeldSendCanBeCanceled(t *testing.T) {
+	t.Parallel()
+
+	guard, next, _, clock := newGasSpikeGuard(t, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := guard.Send(ctx, nil, nil)
+		errc <- err
+	}()
+
+	clock.BlockUntil(1)
+	cancel()
+	require.Equal(t, context.Canceled, <-errc)
+	require.Equal(t, 0, next.calls)
+}
+
+func TestGasSpikeGuardInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := txmgr.NewGasSpikeGuard(&stubTxManager{}, txmgr.GasSpikeConfig{
+		FeeEstimator: &baseFeeEstimator{},
+		PollInterval: time.Minute,
+	})
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
@@ -0,0 +1,64 @@
+package txmgr
+
+import (
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/net/context"
+	"sync"
+	"time"
+)
+
+// headMonitor 跟踪节点最新块高的增长。块高超过 maxStall 没有增长时认为节点卡住或网络停摆，
+// 进入安全模式暂停发送交易，避免对卡住的节点不断加价重发；块高恢复增长后自动退出
+type headMonitor struct {
+	backend  ReceiptSource
+	maxStall time.Duration
+	clock    Clock
+
+	mu           sync.Mutex
+	lastHeight   uint64
+	lastProgress time.Time
+	safeMode     bool
+}
+
+func newHeadMonitor(backend ReceiptSource, maxStall time.Duration, clock Clock) *headMonitor {
+	return &headMonitor{
+		backend:  backend,
+		maxStall: maxStall,
+		clock:    clock,
+	}
+}
+
+// check 查询最新块高并更新安全模式状态，返回是否处于安全模式
+func (h *headMonitor) check(ctx context.Context) bool {
+	height, err := h.backend.BlockNumber(ctx)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.clock.Now()
+	switch {
+	case err != nil:
+		log.Warn("ContractsCaller unable to fetch block number for head monitor", "err", err)
+	case h.lastProgress.IsZero() || height > h.lastHeight:
+		h.lastHeight = height
+		h.lastProgress = now
+	}
+
+	stalled := !h.lastProgress.IsZero() && now.Sub(h.lastProgress) > h.maxStall
+	if stalled && !h.safeMode {
+		log.Error("ContractsCaller chain head stalled, entering safe mode",
+			"height", h.lastHeight, "stalledFor", now.Sub(h.lastProgress))
+	}
+	if !stalled && h.safeMode {
+		log.Info("ContractsCaller chain head advancing, leaving safe mode", "height", h.lastHeight)
+	}
+	h.safeMode = stalled
+	return stalled
+}
+
+func (h *headMonitor) inSafeMode() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.safeMode
+}
@@ -0,0 +1,143 @@
+package txmgr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+	"math/big"
+	"sync"
+)
+
+// MinReplacementBumpPercent 节点接受同 nonce 替换交易所需的最小加价比例
+const MinReplacementBumpPercent = 10
+
+var (
+	// ErrInvalidLadder 阶梯参数不合法
+	ErrInvalidLadder = errors.New("txmgr: invalid escalation ladder")
+	// ErrUnsupportedTxType 阶梯不支持该交易类型
+	ErrUnsupportedTxType = errors.New("txmgr: unsupported transaction type")
+)
+
+// NewEscalationLadder 在发送时预先签好同一 nonce、费用逐级提高 bumpPercent 的 steps 笔替换交易。
+// 返回的 UpdateGasPriceFunc 每次调用返回下一级已签名交易，RPC 抖动或签名服务延迟时加价路径只需要广播；
+// 阶梯用完后一直返回最高一级
+func NewEscalationLadder(
+	from common.Address,
+	tx *types.Transaction,
+	signer bind.SignerFn,
+	steps int,
+	bumpPercent uint64,
+) (UpdateGasPriceFunc, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("%w: steps must be > 0", ErrInvalidLadder)
+	}
+	if bumpPercent < MinReplacementBumpPercent {
+		return nil, fmt.Errorf("%w: bump must be at least %d%%", ErrInvalidLadder, MinReplacementBumpPercent)
+	}
+
+	ladder := make([]*types.Transaction, 0, steps)
+	gasTipCap, gasFeeCap := tx.GasTipCap(), tx.GasFeeCap()
+	for i := 0; i < steps; i++ {
+		if i > 0 {
+			gasTipCap = bumpFee(gasTipCap, bumpPercent)
+			gasFeeCap = bumpFee(gasFeeCap, bumpPercent)
+		}
+
+		txData, err := replacementTxData(tx, gasTipCap, gasFeeCap)
+		if err != nil {
+			return nil, err
+		}
+
+		signed, err := signer(from, types.NewTx(txData))
+		if err != nil {
+			return nil, err
+		}
+		ladder = append(ladder, signed)
+	}
+
+	var (
+		mu   sync.Mutex
+		next int
+	)
+	return func(ctx context.Context) (*types.Transaction, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		tx := ladder[next]
+		if next < len(ladder)-1 {
+			next++
+		}
+		return tx, nil
+	}, nil
+}
+
+// replacementTxData 以新的费用重建同一 nonce 的交易，其余字段保持不变
+func replacementTxData(tx *types.Transaction, gasTipCap, gasFeeCap *big.Int) (types.TxData, error) {
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: gasFeeCap,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}, nil
+	case types.DynamicFeeTxType:
+		return &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	case types.SetCodeTxType:
+		// 替换交易与 EIP-1559 相同，小费和 feeCap 都需要提高；授权列表原样保留
+		tipCap, err := toUint256("GasTipCap", gasTipCap)
+		if err != nil {
+			return nil, err
+		}
+		feeCap, err := toUint256("GasFeeCap", gasFeeCap)
+		if err != nil {
+			return nil, err
+		}
+		// 链 ID 和金额来自已签名的交易，不会溢出
+		chainID, _ := toUint256("ChainID", tx.ChainId())
+		value, _ := toUint256("Value", tx.Value())
+		return &types.SetCodeTx{
+			ChainID:    chainID,
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tipCap,
+			GasFeeCap:  feeCap,
+			Gas:        tx.Gas(),
+			To:         *tx.To(),
+			Value:      value,
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			AuthList:   tx.SetCodeAuthorizations(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedTxType, tx.Type())
+	}
+}
+
+// bumpFee 按百分比提高费用，至少提高 1 wei
+func bumpFee(fee *big.Int, bumpPercent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+bumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
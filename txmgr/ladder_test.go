@@ -0,0 +1,118 @@
+package txmgr_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	dcommon "github.com/DQYXACML/dapplink-vrf/common"
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+func newLadder(t *testing.T, tx *types.Transaction, steps int) (txmgr.UpdateGasPriceFunc, common.Address) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1)
+
+	ladder, err := txmgr.NewEscalationLadder(from, tx, dcommon.PrivateKeySignerFnWithPolicy(key, chainID, dcommon.SignerPolicy{AllowSetCode: true}), steps, 20)
+	require.Nil(t, err)
+	return ladder, from
+}
+
+func TestEscalationLadderDynamicFee(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	ladder, from := newLadder(t, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     7,
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(1000),
+		Gas:       21000,
+		To:        &to,
+	}), 3)
+
+	ctx := context.Background()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	expTipCaps := []int64{100, 120, 144, 144}
+	expFeeCaps := []int64{1000, 1200, 1440, 1440}
+	for i := range expTipCaps {
+		tx, err := ladder(ctx)
+		require.Nil(t, err)
+		require.Equal(t, uint64(7), tx.Nonce())
+		require.Equal(t, big.NewInt(expTipCaps[i]), tx.GasTipCap())
+		require.Equal(t, big.NewInt(expFeeCaps[i]), tx.GasFeeCap())
+
+		sender, err := types.Sender(signer, tx)
+		require.Nil(t, err)
+		require.Equal(t, from, sender)
+	}
+}
+
+func TestEscalationLadderSetCode(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	auth := types.SetCodeAuthorization{
+		Address: common.HexToAddress("0x02"),
+		Nonce:   8,
+	}
+	ladder, from := newLadder(t, types.NewTx(&types.SetCodeTx{
+		ChainID:   uint256.NewInt(1),
+		Nonce:     7,
+		GasTipCap: uint256.NewInt(100),
+		GasFeeCap: uint256.NewInt(1000),
+		Gas:       50000,
+		To:        to,
+		Value:     new(uint256.Int),
+		AuthList:  []types.SetCodeAuthorization{auth},
+	}), 2)
+
+	ctx := context.Background()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	tx, err := ladder(ctx)
+	require.Nil(t, err)
+	tx, err = ladder(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint8(types.SetCodeTxType), tx.Type())
+	require.Equal(t, big.NewInt(120), tx.GasTipCap())
+	require.Equal(t, big.NewInt(1200), tx.GasFeeCap())
+	require.Equal(t, []types.SetCodeAuthorization{auth}, tx.SetCodeAuthorizations())
+
+	sender, err := types.Sender(signer, tx)
+	require.Nil(t, err)
+	require.Equal(t, from, sender)
+}
+
+func TestEscalationLadderLegacy(t *testing.T) {
+	ladder, _ := newLadder(t, types.NewTx(&types.LegacyTx{
+		Nonce:    1,
+		GasPrice: big.NewInt(1),
+		Gas:      21000,
+	}), 2)
+
+	ctx := context.Background()
+	tx, err := ladder(ctx)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(1), tx.GasPrice())
+
+	// Small fees still increase by at least one wei.
+	tx, err = ladder(ctx)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(2), tx.GasPrice())
+}
+
+func TestEscalationLadderRejectsSmallBump(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	_, err = txmgr.NewEscalationLadder(from, types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1)}),
+		dcommon.PrivateKeySignerFn(key, big.NewInt(1)), 3, 5)
+	require.ErrorIs(t, err, txmgr.ErrInvalidLadder)
+}
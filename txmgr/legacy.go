@@ -0,0 +1,32 @@
+package txmgr
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+)
+
+// LegacyTxManager Send 返回 SendResult 之前的接口，只返回交易回执
+//
+// Deprecated: 使用 TxManager，从 SendResult.Receipt 获取回执
+type LegacyTxManager interface {
+	Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTxn SendTransactionFunc) (*types.Receipt, error)
+}
+
+// NewLegacyTxManager 包装 TxManager 并保留只返回回执的 Send，使现有调用方可以逐步迁移
+//
+// Deprecated: 直接使用 TxManager
+func NewLegacyTxManager(mgr TxManager) LegacyTxManager {
+	return &legacyTxManager{mgr: mgr}
+}
+
+type legacyTxManager struct {
+	mgr TxManager
+}
+
+func (l *legacyTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*types.Receipt, error) {
+	result, err := l.mgr.Send(ctx, updateGasPrice, sendTx)
+	if err != nil {
+		return nil, err
+	}
+	return result.Receipt, nil
+}
@@ -0,0 +1,228 @@
+package txmgr
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+	"sync"
+	"time"
+)
+
+// receiptQueryTimeout 每轮批量查询回执的超时时间
+const receiptQueryTimeout = 10 * time.Second
+
+// BatchReceiptSource 支持一次 RPC 批量查询多笔交易回执的 backend。
+// 返回的回执和错误与 txHashes 一一对应，交易未打包时回执为 nil，错误为 nil 或 ethereum.NotFound
+type BatchReceiptSource interface {
+	TransactionReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, []error)
+}
+
+// receiptPoller 为所有在途交易共享一个查询循环：每轮只查询一次块高，
+// backend 支持时批量查询回执，避免每笔交易各自轮询。
+// 配置了 BlockTime 时，已打包但确认数不足的交易推迟到预计只差一个确认时再查询。
+// 查询循环在没有订阅者或 ctx 结束时退出，进行中的查询也随 ctx 取消
+type receiptPoller struct {
+	ctx              context.Context
+	wg               sync.WaitGroup
+	backend          ReceiptSource
+	interval         time.Duration
+	blockTime        time.Duration
+	numConfirmations uint64
+	waitForSafeHead  bool
+	verifyBlockHash  bool
+	skipTipHeight    bool
+	clock            Clock
+
+	mu        sync.Mutex
+	waiters   map[common.Hash]map[chan receiptUpdate]struct{}
+	notBefore map[common.Hash]time.Time // 等待确认的交易下一次查询的时间
+	running   bool
+}
+
+func newReceiptPoller(ctx context.Context, backend ReceiptSource, cfg Config) *receiptPoller {
+	return &receiptPoller{
+		ctx:              ctx,
+		backend:          backend,
+		interval:         cfg.ReceiptQueryInterval,
+		blockTime:        cfg.BlockTime,
+		numConfirmations: cfg.NumConfirmations,
+		waitForSafeHead:  cfg.WaitForSafeHead,
+		verifyBlockHash:  cfg.VerifyReceiptBlockHash,
+		skipTipHeight:    cfg.NumConfirmations == 0,
+		clock:            cfg.Clock,
+		waiters:          make(map[common.Hash]map[chan receiptUpdate]struct{}),
+		notBefore:        make(map[common.Hash]time.Time),
+	}
+}
+
+// subscribe 订阅交易的查询结果，没有订阅者时查询循环自动退出
+func (p *receiptPoller) subscribe(txHash common.Hash) (<-chan receiptUpdate, func()) {
+	updates := make(chan receiptUpdate, 1)
+
+	p.mu.Lock()
+	if p.waiters[txHash] == nil {
+		p.waiters[txHash] = make(map[chan receiptUpdate]struct{})
+	}
+	p.waiters[txHash][updates] = struct{}{}
+	if !p.running && p.ctx.Err() == nil {
+		p.running = true
+		p.wg.Add(1)
+		go p.loop()
+	}
+	p.mu.Unlock()
+
+	unsubscribe := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.waiters[txHash], updates)
+		if len(p.waiters[txHash]) == 0 {
+			delete(p.waiters, txHash)
+			delete(p.notBefore, txHash)
+		}
+	}
+	return updates, unsubscribe
+}
+
+// waitMined 与 waitMined 函数逻辑相同，但回执来自共享的查询循环
+func (p *receiptPoller) waitMined(
+	ctx context.Context,
+	txHash common.Hash,
+	numConfirmations uint64,
+	sendState *SendState,
+) (*types.Receipt, error) {
+	updates, unsubscribe := p.subscribe(txHash)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-p.ctx.Done():
+			return nil, ErrClosed
+		case update := <-updates:
+			if receipt := processReceipt(txHash, update, numConfirmations, sendState); receipt != nil {
+				return receipt, nil
+			}
+		}
+	}
+}
+
+func (p *receiptPoller) loop() {
+	defer p.wg.Done()
+
+	ticker := p.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for p.poll() {
+		select {
+		case <-ticker.Chan():
+		case <-p.ctx.Done():
+			p.mu.Lock()
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// wait 等待查询循环退出，在 ctx 结束后调用
+func (p *receiptPoller) wait() {
+	p.wg.Wait()
+}
+
+// poll 查询一轮所有订阅交易的回执，没有订阅者时返回 false
+func (p *receiptPoller) poll() bool {
+	p.mu.Lock()
+	if len(p.waiters) == 0 {
+		p.running = false
+		p.mu.Unlock()
+		return false
+	}
+	now := p.clock.Now()
+	txHashes := make([]common.Hash, 0, len(p.waiters))
+	for txHash := range p.waiters {
+		if now.Before(p.notBefore[txHash]) {
+			continue
+		}
+		txHashes = append(txHashes, txHash)
+	}
+	p.mu.Unlock()
+	if len(txHashes) == 0 {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, receiptQueryTimeout)
+	defer cancel()
+
+	receipts, errs := p.fetchReceipts(ctx, txHashes)
+
+	var (
+		tipHeight uint64
+		tipErr    error
+		tipLoaded bool
+	)
+	updates := make(map[common.Hash]receiptUpdate, len(txHashes))
+	for i, txHash := range txHashes {
+		update := receiptUpdate{receipt: receipts[i], err: errs[i]}
+		if update.receipt != nil {
+			if !tipLoaded && !p.skipTipHeight {
+				tipHeight, tipErr = confirmationHeight(ctx, p.backend, p.waitForSafeHead)
+				tipLoaded = true
+			}
+			update.tipHeight, update.tipErr = tipHeight, tipErr
+			if p.verifyBlockHash {
+				checkCanonical(ctx, p.backend, &update)
+			}
+		}
+		updates[txHash] = update
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for txHash, update := range updates {
+		p.scheduleLocked(txHash, update, now)
+		for waiter := range p.waiters[txHash] {
+			// 只保留最新的结果
+			select {
+			case <-waiter:
+			default:
+			}
+			waiter <- update
+		}
+	}
+	return true
+}
+
+// scheduleLocked 交易已打包但还差多个确认时，推迟到预计只差一个确认时再查询，
+// 其余情况按 interval 查询。需要持有 p.mu
+func (p *receiptPoller) scheduleLocked(txHash common.Hash, update receiptUpdate, now time.Time) {
+	delete(p.notBefore, txHash)
+	if p.blockTime <= 0 || p.waiters[txHash] == nil {
+		return
+	}
+	receipt := update.receipt
+	if receipt == nil || receipt.BlockNumber == nil || update.tipErr != nil || update.headerErr != nil || update.orphaned {
+		return
+	}
+	confirmedAt := receipt.BlockNumber.Uint64() + p.numConfirmations
+	if confirmedAt <= update.tipHeight+2 {
+		return
+	}
+	confsRemaining := confirmedAt - (update.tipHeight + 1)
+	p.notBefore[txHash] = now.Add(time.Duration(confsRemaining-1) * p.blockTime)
+}
+
+func (p *receiptPoller) fetchReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, []error) {
+	if batch, ok := p.backend.(BatchReceiptSource); ok {
+		return batch.TransactionReceipts(ctx, txHashes)
+	}
+
+	receipts := make([]*types.Receipt, len(txHashes))
+	errs := make([]error, len(txHashes))
+	for i, txHash := range txHashes {
+		receipts[i], errs[i] = p.backend.TransactionReceipt(ctx, txHash)
+	}
+	return receipts, errs
+}
@@ -3,13 +3,71 @@ package txmgr
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
 	"strings"
 	"sync"
 )
 
+// TxState 一次 Send 调用中交易所处的状态
+type TxState uint8
+
+const (
+	TxStateUnsent    TxState = iota // 尚未成功发送
+	TxStateSubmitted                // 已发送，等待打包
+	TxStateMined                    // 已打包，等待确认
+	TxStateConfirmed                // 已达到确认数且执行成功
+	TxStateFailed                   // 已达到确认数但执行失败
+	TxStateAbandoned                // 已放弃，不会再有回执
+)
+
+func (s TxState) String() string {
+	switch s {
+	case TxStateUnsent:
+		return "unsent"
+	case TxStateSubmitted:
+		return "submitted"
+	case TxStateMined:
+		return "mined"
+	case TxStateConfirmed:
+		return "confirmed"
+	case TxStateFailed:
+		return "failed"
+	case TxStateAbandoned:
+		return "abandoned"
+	default:
+		return "unknown"
+	}
+}
+
+// IsTerminal 终止状态之后不再发生状态转换
+func (s TxState) IsTerminal() bool {
+	return s == TxStateConfirmed || s == TxStateFailed || s == TxStateAbandoned
+}
+
+// TransitionHook 状态转换时回调，调用时不持有锁
+type TransitionHook func(from, to TxState)
+
+type sendStateObserverKey struct{}
+
+// WithSendStateObserver 返回的 ctx 传给 Send 后，Send 在首次发送前把本次调用的 SendState 交给 observe。
+// 调用方可在 observe 中注册 OnTransition 回调，或保存 SendState 随时查询 State()
+func WithSendStateObserver(ctx context.Context, observe func(*SendState)) context.Context {
+	return context.WithValue(ctx, sendStateObserverKey{}, observe)
+}
+
+// observeSendState 将 SendState 交给 ctx 中的 observe
+func observeSendState(ctx context.Context, s *SendState) {
+	if observe, ok := ctx.Value(sendStateObserverKey{}).(func(*SendState)); ok {
+		observe(s)
+	}
+}
+
 type SendState struct {
 	minedTxs         map[common.Hash]struct{}
 	nonceTooLowCount uint64
+	state            TxState
+	hooks            []TransitionHook
 	mu               sync.RWMutex
 
 	safeAbortNonceTooLowCount uint64
@@ -22,13 +80,55 @@ func NewSendState(safeAbortNonceTooLowCount uint64) *SendState {
 	return &SendState{
 		minedTxs:                  make(map[common.Hash]struct{}),
 		nonceTooLowCount:          0,
+		state:                     TxStateUnsent,
 		safeAbortNonceTooLowCount: safeAbortNonceTooLowCount,
 	}
 }
 
-// ProcessSendError 处理交易出错，如果是nonce过低，那么记录一下错误次数
+// OnTransition 注册状态转换回调
+func (s *SendState) OnTransition(hook TransitionHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, hook)
+}
+
+// State 返回当前状态
+func (s *SendState) State() TxState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
+}
+
+// transition 切换状态，需持有锁。返回需要在释放锁后执行的回调
+func (s *SendState) transition(to TxState) func() {
+	from := s.state
+	if from == to || from.IsTerminal() {
+		return func() {}
+	}
+	s.state = to
+
+	hooks := append([]TransitionHook(nil), s.hooks...)
+	return func() {
+		for _, hook := range hooks {
+			hook(from, to)
+		}
+	}
+}
+
+// ProcessSendError 处理发送结果。发送成功则进入已发送状态；
+// 如果是nonce过低，那么记录一下错误次数，超过阈值且没有已打包交易时放弃
 func (s *SendState) ProcessSendError(err error) {
 	if err == nil {
+		s.mu.Lock()
+		notify := func() {}
+		if s.state == TxStateUnsent {
+			notify = s.transition(TxStateSubmitted)
+		}
+		s.mu.Unlock()
+
+		notify()
 		return
 	}
 
@@ -37,44 +137,75 @@ func (s *SendState) ProcessSendError(err error) {
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	s.nonceTooLowCount++
+	notify := func() {}
+	if len(s.minedTxs) == 0 && s.nonceTooLowCount >= s.safeAbortNonceTooLowCount {
+		notify = s.transition(TxStateAbandoned)
+	}
+	s.mu.Unlock()
+
+	notify()
 }
 
 func (s *SendState) TxMined(txHash common.Hash) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	s.minedTxs[txHash] = struct{}{}
+	notify := s.transition(TxStateMined)
+	s.mu.Unlock()
+
+	notify()
 }
 
 func (s *SendState) TxNotMined(txHash common.Hash) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	_, wasMined := s.minedTxs[txHash]
 	delete(s.minedTxs, txHash)
 
+	// 已打包的交易被重组掉，回到已发送状态
+	notify := func() {}
 	if len(s.minedTxs) == 0 && wasMined {
 		s.nonceTooLowCount = 0
+		notify = s.transition(TxStateSubmitted)
 	}
+	s.mu.Unlock()
+
+	notify()
 }
 
-// ShouldAbortImmediately nonce过低的交易数超过阈值，停止发送交易
-func (s *SendState) ShouldAbortImmediately() bool {
+// TxConfirmed 交易达到确认数，根据回执状态进入成功或失败
+func (s *SendState) TxConfirmed(receipt *types.Receipt) {
+	to := TxStateConfirmed
+	if receipt.Status == types.ReceiptStatusFailed {
+		to = TxStateFailed
+	}
+
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	notify := s.transition(to)
+	s.mu.Unlock()
 
-	if len(s.minedTxs) > 0 {
-		return false
-	}
+	notify()
+}
+
+// Abandon 放弃交易，之后不会再有回执
+func (s *SendState) Abandon() {
+	s.mu.Lock()
+	notify := s.transition(TxStateAbandoned)
+	s.mu.Unlock()
+
+	notify()
+}
 
-	return s.nonceTooLowCount >= s.safeAbortNonceTooLowCount
+// ShouldAbortImmediately nonce过低的交易数超过阈值，停止发送交易
+func (s *SendState) ShouldAbortImmediately() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state == TxStateAbandoned
 }
 
 func (s *SendState) IsWaitingForConfirmation() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.minedTxs) > 0
+
+	return s.state == TxStateMined
 }
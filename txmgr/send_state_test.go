@@ -8,8 +8,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
 
-	txmgr "github.com/the-web3/contracts-caller/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
 )
 
 const testSafeAbortNonceTooLowCount = 3
@@ -132,3 +133,76 @@ func TestSendStateIsNotWaitingForConfirmationAfterTxUnmined(t *testing.T) {
 	sendState.TxNotMined(testHash)
 	require.False(t, sendState.IsWaitingForConfirmation())
 }
+
+func TestSendStateStartsUnsent(t *testing.T) {
+	sendState := newSendState()
+	require.Equal(t, txmgr.TxStateUnsent, sendState.State())
+}
+
+func TestSendStateSubmittedAfterSuccessfulSend(t *testing.T) {
+	sendState := newSendState()
+
+	sendState.ProcessSendError(nil)
+	require.Equal(t, txmgr.TxStateSubmitted, sendState.State())
+}
+
+func TestSendStateMinedAndReorged(t *testing.T) {
+	sendState := newSendState()
+
+	sendState.ProcessSendError(nil)
+	sendState.TxMined(testHash)
+	require.Equal(t, txmgr.TxStateMined, sendState.State())
+	sendState.TxNotMined(testHash)
+	require.Equal(t, txmgr.TxStateSubmitted, sendState.State())
+}
+
+func TestSendStateConfirmedAndFailed(t *testing.T) {
+	sendState := newSendState()
+
+	sendState.TxMined(testHash)
+	sendState.TxConfirmed(&types.Receipt{Status: types.ReceiptStatusSuccessful})
+	require.Equal(t, txmgr.TxStateConfirmed, sendState.State())
+
+	sendState = newSendState()
+	sendState.TxMined(testHash)
+	sendState.TxConfirmed(&types.Receipt{Status: types.ReceiptStatusFailed})
+	require.Equal(t, txmgr.TxStateFailed, sendState.State())
+}
+
+func TestSendStateAbandonedAfterNonceTooLow(t *testing.T) {
+	sendState := newSendState()
+
+	processNSendErrors(
+		sendState, core.ErrNonceTooLow, testSafeAbortNonceTooLowCount,
+	)
+	require.Equal(t, txmgr.TxStateAbandoned, sendState.State())
+}
+
+func TestSendStateTerminalStateIsFinal(t *testing.T) {
+	sendState := newSendState()
+
+	sendState.Abandon()
+	sendState.TxMined(testHash)
+	require.Equal(t, txmgr.TxStateAbandoned, sendState.State())
+	require.False(t, sendState.IsWaitingForConfirmation())
+}
+
+func TestSendStateTransitionHooks(t *testing.T) {
+	sendState := newSendState()
+
+	var transitions [][2]txmgr.TxState
+	sendState.OnTransition(func(from, to txmgr.TxState) {
+		transitions = append(transitions, [2]txmgr.TxState{from, to})
+	})
+
+	sendState.ProcessSendError(nil)
+	sendState.TxMined(testHash)
+	sendState.TxMined(common.HexToHash("0x02"))
+	sendState.TxConfirmed(&types.Receipt{Status: types.ReceiptStatusSuccessful})
+
+	require.Equal(t, [][2]txmgr.TxState{
+		{txmgr.TxStateUnsent, txmgr.TxStateSubmitted},
+		{txmgr.TxStateSubmitted, txmgr.TxStateMined},
+		{txmgr.TxStateMined, txmgr.TxStateConfirmed},
+	}, transitions)
+}
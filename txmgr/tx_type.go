@@ -0,0 +1,217 @@
+package txmgr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrInvalidSetCodeTx EIP-7702 交易缺少接收地址或授权列表
+	ErrInvalidSetCodeTx = errors.New("txmgr: invalid set code transaction")
+	// ErrInvalidTxParams 构建交易的参数缺失、为负数或超出 uint256 范围
+	ErrInvalidTxParams = errors.New("txmgr: invalid transaction params")
+)
+
+// TxTypeMode 链使用的交易类型。部分链返回 baseFee 却不接受 EIP-1559 交易，或者相反，需要按链强制指定
+type TxTypeMode uint8
+
+const (
+	TxTypeAuto       TxTypeMode = iota // 根据区块头是否包含 baseFee 自动选择
+	TxTypeLegacy                       // 强制使用 legacy 交易
+	TxTypeDynamicFee                   // 强制使用 EIP-1559 交易
+	TxTypeSetCode                      // 强制使用 EIP-7702 交易，发送地址是委托了合约代码的 EOA
+)
+
+func (m TxTypeMode) String() string {
+	switch m {
+	case TxTypeAuto:
+		return "auto"
+	case TxTypeLegacy:
+		return "legacy"
+	case TxTypeDynamicFee:
+		return "eip1559"
+	case TxTypeSetCode:
+		return "eip7702"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTxTypeMode 解析配置中的交易类型
+func ParseTxTypeMode(s string) (TxTypeMode, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return TxTypeAuto, nil
+	case "legacy":
+		return TxTypeLegacy, nil
+	case "eip1559", "dynamic":
+		return TxTypeDynamicFee, nil
+	case "eip7702", "setcode":
+		return TxTypeSetCode, nil
+	default:
+		return 0, fmt.Errorf("txmgr: unknown transaction type %q", s)
+	}
+}
+
+// ResolveTxType 根据配置和最新区块头确定实际使用的交易类型
+func ResolveTxType(mode TxTypeMode, head *types.Header) uint8 {
+	switch mode {
+	case TxTypeLegacy:
+		return types.LegacyTxType
+	case TxTypeDynamicFee:
+		return types.DynamicFeeTxType
+	case TxTypeSetCode:
+		return types.SetCodeTxType
+	default:
+		if head != nil && head.BaseFee != nil {
+			return types.DynamicFeeTxType
+		}
+		return types.LegacyTxType
+	}
+}
+
+// TxParams 构建交易所需的参数
+type TxParams struct {
+	ChainID   *big.Int
+	Nonce     uint64
+	To        *common.Address
+	Value     *big.Int
+	Gas       uint64
+	Data      []byte
+	GasTipCap *big.Int
+	// AuthList EIP-7702 授权列表，非空时使用 EIP-7702 交易。
+	// 发送地址为自己授权时，授权的 nonce 必须是交易 nonce 加一
+	AuthList []types.SetCodeAuthorization
+}
+
+// validate 检查金额参数：GasTipCap 必填，所有金额不能为负数或超出 uint256
+func (p TxParams) validate() error {
+	if p.GasTipCap == nil {
+		return fmt.Errorf("%w: GasTipCap required", ErrInvalidTxParams)
+	}
+	for _, field := range []struct {
+		name  string
+		value *big.Int
+	}{
+		{"ChainID", p.ChainID},
+		{"Value", p.Value},
+		{"GasTipCap", p.GasTipCap},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if field.value.Sign() < 0 {
+			return fmt.Errorf("%w: %s is negative", ErrInvalidTxParams, field.name)
+		}
+		if field.value.BitLen() > 256 {
+			return fmt.Errorf("%w: %s overflows uint256", ErrInvalidTxParams, field.name)
+		}
+	}
+	return nil
+}
+
+// NewTxData 按交易类型构建未签名交易。EIP-1559 和 EIP-7702 交易的 gasFeeCap 由 CalcGasFeeCap 计算；
+// legacy 交易的 gasPrice 为 baseFee 加 gasTipCap，没有 baseFee 时直接使用 gasTipCap。
+// EIP-1559 交易带有授权列表时升级为 EIP-7702 交易，legacy 交易带有授权列表时返回 ErrInvalidSetCodeTx；
+// 参数不合法时返回 ErrInvalidTxParams
+func NewTxData(mode TxTypeMode, head *types.Header, params TxParams) (types.TxData, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	var baseFee *big.Int
+	if head != nil {
+		baseFee = head.BaseFee
+	}
+
+	txType := ResolveTxType(mode, head)
+	if len(params.AuthList) > 0 {
+		switch txType {
+		case types.DynamicFeeTxType:
+			txType = types.SetCodeTxType
+		case types.LegacyTxType:
+			// legacy 交易无法携带授权列表，不能静默丢弃授权
+			return nil, fmt.Errorf("%w: authorization list requires a dynamic fee transaction", ErrInvalidSetCodeTx)
+		}
+	}
+
+	switch txType {
+	case types.SetCodeTxType:
+		// EIP-7702 交易不能创建合约，且至少包含一条授权
+		if params.To == nil {
+			return nil, fmt.Errorf("%w: recipient required", ErrInvalidSetCodeTx)
+		}
+		if len(params.AuthList) == 0 {
+			return nil, fmt.Errorf("%w: authorization list required", ErrInvalidSetCodeTx)
+		}
+		if baseFee == nil {
+			baseFee = new(big.Int)
+		}
+		gasFeeCap, err := toUint256("GasFeeCap", CalcGasFeeCap(baseFee, params.GasTipCap))
+		if err != nil {
+			return nil, err
+		}
+		// 其余字段已由 validate 检查，不会溢出
+		chainID, _ := toUint256("ChainID", params.ChainID)
+		gasTipCap, _ := toUint256("GasTipCap", params.GasTipCap)
+		value, _ := toUint256("Value", params.Value)
+		return &types.SetCodeTx{
+			ChainID:   chainID,
+			Nonce:     params.Nonce,
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       params.Gas,
+			To:        *params.To,
+			Value:     value,
+			Data:      params.Data,
+			AuthList:  params.AuthList,
+		}, nil
+	case types.DynamicFeeTxType:
+		if baseFee == nil {
+			baseFee = new(big.Int)
+		}
+		return &types.DynamicFeeTx{
+			ChainID:   params.ChainID,
+			Nonce:     params.Nonce,
+			GasTipCap: params.GasTipCap,
+			GasFeeCap: CalcGasFeeCap(baseFee, params.GasTipCap),
+			Gas:       params.Gas,
+			To:        params.To,
+			Value:     params.Value,
+			Data:      params.Data,
+		}, nil
+	default:
+		gasPrice := new(big.Int).Set(params.GasTipCap)
+		if baseFee != nil {
+			gasPrice.Add(gasPrice, baseFee)
+		}
+		return &types.LegacyTx{
+			Nonce:    params.Nonce,
+			GasPrice: gasPrice,
+			Gas:      params.Gas,
+			To:       params.To,
+			Value:    params.Value,
+			Data:     params.Data,
+		}, nil
+	}
+}
+
+// toUint256 转换为 EIP-7702 交易使用的 uint256，nil 视为 0，负数或溢出时返回 ErrInvalidTxParams
+func toUint256(name string, v *big.Int) (*uint256.Int, error) {
+	if v == nil {
+		return new(uint256.Int), nil
+	}
+	if v.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %s is negative", ErrInvalidTxParams, name)
+	}
+	u, overflow := uint256.FromBig(v)
+	if overflow {
+		return nil, fmt.Errorf("%w: %s overflows uint256", ErrInvalidTxParams, name)
+	}
+	return u, nil
+}
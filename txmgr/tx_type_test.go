@@ -0,0 +1,209 @@
+package txmgr_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseTxTypeMode(t *testing.T) {
+	for s, mode := range map[string]txmgr.TxTypeMode{
+		"":        txmgr.TxTypeAuto,
+		"auto":    txmgr.TxTypeAuto,
+		"Legacy":  txmgr.TxTypeLegacy,
+		"eip1559": txmgr.TxTypeDynamicFee,
+		"eip7702": txmgr.TxTypeSetCode,
+	} {
+		parsed, err := txmgr.ParseTxTypeMode(s)
+		require.Nil(t, err)
+		require.Equal(t, mode, parsed)
+	}
+
+	_, err := txmgr.ParseTxTypeMode("blob")
+	require.NotNil(t, err)
+}
+
+func TestResolveTxType(t *testing.T) {
+	withBaseFee := &types.Header{BaseFee: big.NewInt(7)}
+	withoutBaseFee := &types.Header{}
+
+	require.Equal(t, uint8(types.DynamicFeeTxType), txmgr.ResolveTxType(txmgr.TxTypeAuto, withBaseFee))
+	require.Equal(t, uint8(types.LegacyTxType), txmgr.ResolveTxType(txmgr.TxTypeAuto, withoutBaseFee))
+	require.Equal(t, uint8(types.LegacyTxType), txmgr.ResolveTxType(txmgr.TxTypeLegacy, withBaseFee))
+	require.Equal(t, uint8(types.DynamicFeeTxType), txmgr.ResolveTxType(txmgr.TxTypeDynamicFee, withoutBaseFee))
+	require.Equal(t, uint8(types.SetCodeTxType), txmgr.ResolveTxType(txmgr.TxTypeSetCode, withBaseFee))
+}
+
+func TestNewTxData(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	head := &types.Header{BaseFee: big.NewInt(7)}
+	params := txmgr.TxParams{
+		ChainID:   big.NewInt(1),
+		Nonce:     3,
+		To:        &to,
+		Value:     new(big.Int),
+		Gas:       21000,
+		GasTipCap: big.NewInt(5),
+	}
+
+	txData, err := txmgr.NewTxData(txmgr.TxTypeAuto, head, params)
+	require.Nil(t, err)
+	tx := types.NewTx(txData)
+	require.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	require.Equal(t, big.NewInt(5), tx.GasTipCap())
+	require.Equal(t, big.NewInt(19), tx.GasFeeCap())
+
+	txData, err = txmgr.NewTxData(txmgr.TxTypeLegacy, head, params)
+	require.Nil(t, err)
+	tx = types.NewTx(txData)
+	require.Equal(t, uint8(types.LegacyTxType), tx.Type())
+	require.Equal(t, big.NewInt(12), tx.GasPrice())
+	require.Equal(t, uint64(3), tx.Nonce())
+}
+
+func TestNewTxDataSetCode(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	head := &types.Header{BaseFee: big.NewInt(7)}
+	auth := types.SetCodeAuthorization{
+		Address: common.HexToAddress("0x02"),
+		Nonce:   4,
+	}
+	params := txmgr.TxParams{
+		ChainID:   big.NewInt(1),
+		Nonce:     3,
+		To:        &to,
+		Gas:       21000,
+		GasTipCap: big.NewInt(5),
+		AuthList:  []types.SetCodeAuthorization{auth},
+	}
+
+	// 带授权列表的 EIP-1559 交易升级为 EIP-7702 交易
+	txData, err := txmgr.NewTxData(txmgr.TxTypeAuto, head, params)
+	require.Nil(t, err)
+	tx := types.NewTx(txData)
+	require.Equal(t, uint8(types.SetCodeTxType), tx.Type())
+	require.Equal(t, big.NewInt(5), tx.GasTipCap())
+	require.Equal(t, big.NewInt(19), tx.GasFeeCap())
+	require.Equal(t, []types.SetCodeAuthorization{auth}, tx.SetCodeAuthorizations())
+	require.Equal(t, big.NewInt(0), tx.Value())
+
+	// legacy 交易无法携带授权列表
+	_, err = txmgr.NewTxData(txmgr.TxTypeLegacy, head, params)
+	require.ErrorIs(t, err, txmgr.ErrInvalidSetCodeTx)
+	_, err = txmgr.NewTxData(txmgr.TxTypeAuto, &types.Header{}, params)
+	require.ErrorIs(t, err, txmgr.ErrInvalidSetCodeTx)
+
+	params.To = nil
+	_, err = txmgr.NewTxData(txmgr.TxTypeSetCode, head, params)
+	require.ErrorIs(t, err, txmgr.ErrInvalidSetCodeTx)
+
+	params.To = &to
+	params.AuthList = nil
+	_, err = txmgr.NewTxData(txmgr.TxTypeSetCode, head, params)
+	require.ErrorIs(t, err, txmgr.ErrInvalidSetCodeTx)
+}
+
+func TestNewTxDataInvalidParams(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	head := &types.Header{BaseFee: big.NewInt(7)}
+	huge := new(big.Int).Lsh(big.NewInt(1), 256)
+	// gasTipCap 本身不溢出，但 2*baseFee+gasTipCap 超出 uint256
+	maxUint256 := new(big.Int).Sub(huge, big.NewInt(1))
+	auth := []types.SetCodeAuthorization{{Address: common.HexToAddress("0x02")}}
+
+	tests := []struct {
+		name   string
+		mode   txmgr.TxTypeMode
+		head   *types.Header
+		params txmgr.TxParams
+	}{
+		{"nil tip legacy", txmgr.TxTypeLegacy, head, txmgr.TxParams{To: &to}},
+		{"nil tip dynamic", txmgr.TxTypeDynamicFee, head, txmgr.TxParams{To: &to}},
+		{"nil tip set code", txmgr.TxTypeSetCode, head, txmgr.TxParams{To: &to, AuthList: auth}},
+		{"negative tip", txmgr.TxTypeDynamicFee, head, txmgr.TxParams{To: &to, GasTipCap: big.NewInt(-1)}},
+		{"negative value", txmgr.TxTypeLegacy, head, txmgr.TxParams{To: &to, GasTipCap: big.NewInt(1), Value: big.NewInt(-1)}},
+		{"negative chain id", txmgr.TxTypeSetCode, head, txmgr.TxParams{ChainID: big.NewInt(-1), To: &to, GasTipCap: big.NewInt(1), AuthList: auth}},
+		{"oversized value", txmgr.TxTypeSetCode, head, txmgr.TxParams{To: &to, GasTipCap: big.NewInt(1), Value: huge, AuthList: auth}},
+		{"oversized tip", txmgr.TxTypeDynamicFee, head, txmgr.TxParams{To: &to, GasTipCap: huge}},
+		{"oversized fee cap", txmgr.TxTypeSetCode, head, txmgr.TxParams{To: &to, GasTipCap: maxUint256, AuthList: auth}},
+	}
+	for _, test := range tests {
+		var txData types.TxData
+		var err error
+		require.NotPanics(t, func() {
+			txData, err = txmgr.NewTxData(test.mode, test.head, test.params)
+		}, test.name)
+		require.ErrorIs(t, err, txmgr.ErrInvalidTxParams, test.name)
+		require.Nil(t, txData, test.name)
+	}
+}
+
+func TestTxMgrNewTxDataUsesConfiguredTxType(t *testing.T) {
+	t.Parallel()
+
+	backend := txmgrtest.NewFakeReceiptSource()
+	backend.SetBaseFee(big.NewInt(7))
+	backend.Mine()
+
+	to := common.HexToAddress("0x01")
+	params := txmgr.TxParams{
+		ChainID:   big.NewInt(1),
+		To:        &to,
+		Value:     new(big.Int),
+		Gas:       21000,
+		GasTipCap: big.NewInt(5),
+	}
+
+	for mode, txType := range map[txmgr.TxTypeMode]uint8{
+		txmgr.TxTypeAuto:       types.DynamicFeeTxType,
+		txmgr.TxTypeLegacy:     types.LegacyTxType,
+		txmgr.TxTypeDynamicFee: types.DynamicFeeTxType,
+	} {
+		cfg := configWithNumConfs(1)
+		cfg.TxTypeMode = mode
+		mgr := newTxManager(t, cfg, backend)
+		sender := txmgrtest.NewFakeSender(backend, true)
+
+		// 首次发送和加价重发都通过 NewTxData 构建，交易类型由配置决定
+		updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+			txData, err := mgr.NewTxData(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			return types.NewTx(txData), nil
+		}
+		_, err := mgr.Send(context.Background(), updateGasPrice, sender.Send)
+		require.Nil(t, err)
+		sent := sender.Sent()
+		require.Len(t, sent, 1)
+		require.Equal(t, txType, sent[0].Type(), mode)
+	}
+}
+
+func TestTxMgrNewTxDataRequiresHeaders(t *testing.T) {
+	t.Parallel()
+
+	mgr := newTxManager(t, configWithNumConfs(1), newMockBackend())
+	_, err := mgr.NewTxData(context.Background(), txmgr.TxParams{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(5),
+	})
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
+
+func TestConfigRejectsUnknownTxTypeMode(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.TxTypeMode = txmgr.TxTypeSetCode + 1
+	_, err := txmgr.NewSimpleTxManager(cfg, newMockBackend())
+	require.True(t, errors.Is(err, txmgr.ErrInvalidConfig))
+}
@@ -2,29 +2,164 @@ package txmgr
 
 import (
 	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 	"golang.org/x/net/context"
 	"math/big"
+	"math/rand/v2"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	// ErrNonceTooLowAbort nonce 过低的次数达到阈值，交易被放弃，调用方需要用新的 gas 参数重新发送
+	ErrNonceTooLowAbort = errors.New("txmgr: transaction abandoned after repeated nonce too low errors")
+	// ErrUpdateGasPrice 构建交易失败，交易被放弃
+	ErrUpdateGasPrice = errors.New("txmgr: failed to update transaction gas price")
+	// ErrInvalidConfig 配置不合法
+	ErrInvalidConfig = errors.New("txmgr: invalid config")
+	// ErrSkipBump UpdateGasPriceFunc 返回该错误表示本轮不加价，Send 不重发，继续等待在途交易
+	ErrSkipBump = errors.New("txmgr: skip fee bump")
+	// ErrClosed SimpleTxManager 已关闭
+	ErrClosed = errors.New("txmgr: tx manager closed")
+)
+
 type UpdateGasPriceFunc = func(ctx context.Context) (*types.Transaction, error)
 
 type SendTransactionFunc = func(ctx context.Context, tx *types.Transaction) error
 
 type Config struct {
-	ResubmissionTimeout       time.Duration // 重新提交交易的时间间隔
-	ReceiptQueryInterval      time.Duration // 查询交易回执的时间间隔
-	NumConfirmations          uint64        // 交易需要的最小确认数
-	SafeAbortNonceTooLowCount uint64        // 发送交易后， nonce 值过低报错出现的次数
+	ResubmissionTimeout       time.Duration  // 重新提交交易的时间间隔
+	ReceiptQueryInterval      time.Duration  // 查询交易回执的时间间隔
+	NumConfirmations          uint64         // 交易需要的最小确认数，0 表示拿到回执即确认，适用于即时最终性的开发链和 L2
+	SafeAbortNonceTooLowCount uint64         // 发送交易后， nonce 值过低报错出现的次数
+	MaxSubmissionDelay        time.Duration  // 首次发送前随机等待的最大时长，0 表示不等待，用于降低发送时机的可预测性；不超过 ctx 剩余时间的一半
+	MaxInFlight               uint64         // 同一发送地址允许并发执行的 Send 数量，0 表示不限制，超出的调用排队等待
+	WaitForSafeHead           bool           // 以 safe 块高（OP Stack 中由 L1 推导出的 L2 块）计算确认数，需要 backend 实现 HeaderSource
+	MaxHeadStall              time.Duration  // 最新块高超过该时长未增长时进入安全模式，暂停发送和加价，0 表示不检测
+	BumpSkipPercentile        float64        // 加价前检查在途交易的费用是否仍高于该分位，是则不加价、只重新广播在途交易，0 表示总是加价
+	FeeEstimator              FeeEstimator   // 加价前检查使用的费用来源，为空时使用实现了 FeeHistoryReader 的 backend
+	Clock                     Clock          // 定时器使用的时间来源，为空时使用 SystemClock
+	VerifyReceiptBlockHash    bool           // 检查回执所在块是否仍在规范链上，被重组掉的回执视为未打包，需要 backend 实现 HeaderSource
+	MaxPendingSends           uint64         // 未返回的 Send（含排队中的）达到该值时报告背压，0 表示不检测
+	MaxNonceSpan              uint64         // 发送地址 pending 与 latest nonce 之差达到该值时报告背压，需要 backend 实现 NonceSource，0 表示不检测
+	Sender                    common.Address // 发送地址，MaxNonceSpan > 0 时必填
+	BlockTime                 time.Duration  // 链的平均出块时间，设置后未配置的 ResubmissionTimeout 和 ReceiptQueryInterval 按出块时间推导，已打包的交易在预计只差一个确认前不再查询回执，见 BlockTimeForChain
+	TxTypeMode                TxTypeMode     // 链使用的交易类型，SimpleTxManager.NewTxData 按此构建交易，默认 TxTypeAuto 按最新区块头是否包含 baseFee 选择
+}
+
+// validate 检查配置是否合法
+func (cfg Config) validate(backend ReceiptSource) error {
+	switch {
+	case cfg.ResubmissionTimeout <= 0:
+		return fmt.Errorf("%w: ResubmissionTimeout must be > 0", ErrInvalidConfig)
+	case cfg.ReceiptQueryInterval <= 0:
+		return fmt.Errorf("%w: ReceiptQueryInterval must be > 0", ErrInvalidConfig)
+	case cfg.SafeAbortNonceTooLowCount == 0:
+		return fmt.Errorf("%w: SafeAbortNonceTooLowCount must be > 0", ErrInvalidConfig)
+	case cfg.BumpSkipPercentile < 0 || cfg.BumpSkipPercentile > 100:
+		return fmt.Errorf("%w: BumpSkipPercentile must be within [0, 100]", ErrInvalidConfig)
+	case cfg.WaitForSafeHead && cfg.NumConfirmations == 0:
+		return fmt.Errorf("%w: WaitForSafeHead requires NumConfirmations > 0", ErrInvalidConfig)
+	case cfg.TxTypeMode > TxTypeSetCode:
+		return fmt.Errorf("%w: unknown TxTypeMode %d", ErrInvalidConfig, cfg.TxTypeMode)
+	}
+
+	_, hasHeaders := backend.(HeaderSource)
+	if cfg.WaitForSafeHead && !hasHeaders {
+		return fmt.Errorf("%w: WaitForSafeHead requires a backend implementing HeaderSource", ErrInvalidConfig)
+	}
+	if cfg.VerifyReceiptBlockHash && !hasHeaders {
+		return fmt.Errorf("%w: VerifyReceiptBlockHash requires a backend implementing HeaderSource", ErrInvalidConfig)
+	}
+	if _, ok := backend.(FeeHistoryReader); cfg.BumpSkipPercentile > 0 && cfg.FeeEstimator == nil && !ok {
+		return fmt.Errorf("%w: BumpSkipPercentile requires a FeeEstimator or a backend implementing FeeHistoryReader", ErrInvalidConfig)
+	}
+	if cfg.MaxNonceSpan > 0 {
+		if _, ok := backend.(NonceSource); !ok {
+			return fmt.Errorf("%w: MaxNonceSpan requires a backend implementing NonceSource", ErrInvalidConfig)
+		}
+		if cfg.Sender == (common.Address{}) {
+			return fmt.Errorf("%w: MaxNonceSpan requires Sender", ErrInvalidConfig)
+		}
+	}
+	return nil
 }
 
 type TxManager interface {
-	Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTxn SendTransactionFunc) (*types.Receipt, error) // 发送并获取交易回执
+	Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTxn SendTransactionFunc) (*SendResult, error) // 发送并获取交易回执及费用明细
+}
+
+// SendResult Send 的结果，包含回执以及用于成本核算的费用明细
+type SendResult struct {
+	Receipt           *types.Receipt
+	Attempts          uint64        // 成功广播的不同交易笔数（按交易哈希计），包括被替换的交易；重新广播同一笔交易不计入
+	EffectiveGasPrice *big.Int      // 上链交易的实际 gas 价格。节点未返回时按交易计算：legacy 交易为 gasPrice，EIP-1559 等交易为 min(feeCap, 所在块 baseFee + tipCap)，backend 未实现 HeaderSource 或查询区块头失败时为 nil
+	FeePaid           *big.Int      // 实际支付的交易费，即 gasUsed 乘以 EffectiveGasPrice，EffectiveGasPrice 为 nil 时为 nil；被替换的交易没有上链，不产生费用
+	WaitDuration      time.Duration // 从调用 Send 到交易确认的时长
+}
+
+// newSendResult 根据上链的交易和回执计算费用明细，baseFee 为交易所在块的 baseFee，未知时为 nil
+func newSendResult(tx *types.Transaction, receipt *types.Receipt, baseFee *big.Int, attempts uint64, wait time.Duration) *SendResult {
+	result := &SendResult{
+		Receipt:           receipt,
+		Attempts:          attempts,
+		EffectiveGasPrice: effectiveGasPrice(tx, receipt, baseFee),
+		WaitDuration:      wait,
+	}
+	if result.EffectiveGasPrice != nil {
+		result.FeePaid = new(big.Int).Mul(result.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	}
+	return result
+}
+
+// effectiveGasPrice 回执中没有 effectiveGasPrice 时按交易和所在块的 baseFee 计算，无法计算时返回 nil
+func effectiveGasPrice(tx *types.Transaction, receipt *types.Receipt, baseFee *big.Int) *big.Int {
+	if receipt.EffectiveGasPrice != nil {
+		return receipt.EffectiveGasPrice
+	}
+	if !hasDynamicFee(tx) {
+		return tx.GasPrice()
+	}
+	if baseFee == nil {
+		return nil
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		price.Set(tx.GasFeeCap())
+	}
+	return price
+}
+
+func hasDynamicFee(tx *types.Transaction) bool {
+	return tx.Type() != types.LegacyTxType && tx.Type() != types.AccessListTxType
+}
+
+// inclusionBaseFee 查询交易所在块的 baseFee，只在需要自行计算 effectiveGasPrice 时查询，
+// backend 未实现 HeaderSource 或查询失败时返回 nil
+func (m *SimpleTxManager) inclusionBaseFee(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) *big.Int {
+	if receipt.EffectiveGasPrice != nil || !hasDynamicFee(tx) || receipt.BlockNumber == nil {
+		return nil
+	}
+	headers, ok := m.backend.(HeaderSource)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, feeQueryTimeout)
+	defer cancel()
+
+	header, err := headers.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		log.Warn("ContractsCaller unable to fetch inclusion block header", "block", receipt.BlockNumber, "err", err)
+		return nil
+	}
+	return header.BaseFee
 }
 
 type ReceiptSource interface {
@@ -32,44 +167,181 @@ type ReceiptSource interface {
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) // 根据交易哈希获取交易回执
 }
 
+// HeaderSource 按块高查询区块头，可传入 rpc.SafeBlockNumber 等特殊块高
+type HeaderSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Backend 可查询区块头的 ReceiptSource，*ethclient.Client 实现了该接口
+type Backend interface {
+	ReceiptSource
+	HeaderSource
+}
+
 type SimpleTxManager struct {
-	cfg     Config
-	backend ReceiptSource
-	l       log.Logger
+	cfg      Config
+	backend  ReceiptSource
+	l        log.Logger
+	inFlight chan struct{}
+	poller   *receiptPoller
+	head     *headMonitor
+	pending  atomic.Int64
+
+	// ctx 管理器的生命周期，Close 时取消，结束共享的回执查询循环和进行中的 Send
+	ctx   context.Context
+	close context.CancelFunc
 }
 
-func NewSimpleTxManager(cfg Config, backend ReceiptSource) *SimpleTxManager {
-	if cfg.NumConfirmations == 0 {
-		panic("txmgr: NumConfirmations must be > 0")
+func NewSimpleTxManager(cfg Config, backend ReceiptSource) (*SimpleTxManager, error) {
+	cfg = cfg.WithBlockTimeDefaults()
+	if err := cfg.validate(backend); err != nil {
+		return nil, err
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+	if cfg.BumpSkipPercentile > 0 && cfg.FeeEstimator == nil {
+		cfg.FeeEstimator = NewFeeHistoryEstimator(backend.(FeeHistoryReader))
+	}
+	var inFlight chan struct{}
+	if cfg.MaxInFlight > 0 {
+		inFlight = make(chan struct{}, cfg.MaxInFlight)
+	}
+	var head *headMonitor
+	if cfg.MaxHeadStall > 0 {
+		head = newHeadMonitor(backend, cfg.MaxHeadStall, cfg.Clock)
 	}
+	ctx, closeFn := context.WithCancel(context.Background())
 	return &SimpleTxManager{
-		cfg:     cfg,
-		backend: backend,
+		cfg:      cfg,
+		backend:  backend,
+		inFlight: inFlight,
+		poller:   newReceiptPoller(ctx, backend, cfg),
+		head:     head,
+		ctx:      ctx,
+		close:    closeFn,
+	}, nil
+}
+
+// Close 关闭管理器：进行中的 Send 返回 ErrClosed，之后的 Send 直接返回 ErrClosed。
+// 返回时共享的回执查询循环已经退出
+func (m *SimpleTxManager) Close() {
+	m.close()
+	m.poller.wait()
+}
+
+// InSafeMode 链的最新块高停止增长，暂停发送交易
+func (m *SimpleTxManager) InSafeMode() bool {
+	if m.head == nil {
+		return false
 	}
+	return m.head.inSafeMode()
 }
 
-func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*types.Receipt, error) {
+// NewTxData 按 Config.TxTypeMode 和最新区块头构建未签名交易，在 UpdateGasPriceFunc 中调用，
+// 首次发送和加价重发都使用按链配置的交易类型。需要 backend 实现 HeaderSource
+func (m *SimpleTxManager) NewTxData(ctx context.Context, params TxParams) (types.TxData, error) {
+	headers, ok := m.backend.(HeaderSource)
+	if !ok {
+		return nil, fmt.Errorf("%w: NewTxData requires a backend implementing HeaderSource", ErrInvalidConfig)
+	}
+	head, err := headers.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewTxData(m.cfg.TxTypeMode, head, params)
+}
+
+func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*SendResult, error) {
+	m.pending.Add(1)
+	defer m.pending.Add(-1)
+
+	if m.ctx.Err() != nil {
+		return nil, ErrClosed
+	}
+	start := m.cfg.Clock.Now()
+
+	// 一个 SimpleTxManager 对应一个发送地址，限制并发的 Send 数量
+	if m.inFlight != nil {
+		select {
+		case m.inFlight <- struct{}{}:
+			defer func() { <-m.inFlight }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-m.ctx.Done():
+			return nil, ErrClosed
+		}
+	}
+
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
 	ctxc, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// 在允许的窗口内随机推迟首次发送，ctx 有截止时间时最多等待剩余时间的一半，
+	// 为发送和确认留出时间
+	if m.cfg.MaxSubmissionDelay > 0 {
+		delay := rand.N(m.cfg.MaxSubmissionDelay)
+		if deadline, ok := ctx.Deadline(); ok {
+			delay = max(min(delay, deadline.Sub(m.cfg.Clock.Now())/2), 0)
+		}
+		log.Debug("ContractsCaller delaying transaction submission", "delay", delay)
+		select {
+		case <-m.cfg.Clock.After(delay):
+		case <-ctxc.Done():
+			return nil, ctxc.Err()
+		case <-m.ctx.Done():
+			return nil, ErrClosed
+		}
+	}
+
 	sendState := NewSendState(m.cfg.SafeAbortNonceTooLowCount)
+	observeSendState(ctx, sendState)
+
+	// 记录放弃交易的原因，使调用方能区分主动放弃和外部取消
+	var (
+		abortMu  sync.Mutex
+		abortErr error
+	)
+	abort := func(err error) {
+		abortMu.Lock()
+		if abortErr == nil {
+			abortErr = err
+		}
+		abortMu.Unlock()
+		cancel()
+	}
 
-	receiptChan := make(chan *types.Receipt, 1)
+	// 最近一次发送成功的交易，用于加价前检查费用是否仍有竞争力；
+	// published 记录已广播过的交易哈希，同一笔交易只计一次 Attempts、只等待一次回执
+	var (
+		lastTxMu  sync.Mutex
+		lastTx    *types.Transaction
+		published = make(map[common.Hash]struct{})
+	)
+
+	type minedTx struct {
+		tx      *types.Transaction
+		receipt *types.Receipt
+	}
+	receiptChan := make(chan minedTx, 1)
 	sendTxAsync := func() {
 		defer wg.Done()
 
 		// 构建交易
 		tx, err := updateGasPrice(ctxc) // 更新gas
+		if errors.Is(err, ErrSkipBump) {
+			log.Debug("ContractsCaller fee bump skipped, waiting for in-flight transaction")
+			return
+		}
 		if err != nil {
-			// 被取消了的话
-			if errors.Is(err, context.Canceled) || strings.Contains(err.Error(), "context canceled") {
+			// 被取消或已到截止时间的话
+			if ctxc.Err() != nil || errors.Is(err, context.Canceled) || strings.Contains(err.Error(), "context canceled") {
 				return
 			}
 			log.Error("ContractsCaller update txn gas price fail", "err", err)
-			cancel() // 向下传递取消
+			abort(fmt.Errorf("%w: %w", ErrUpdateGasPrice, err)) // 向下传递取消
 			return
 		}
 
@@ -88,21 +360,30 @@ func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPric
 			}
 			log.Error("ContractsCaller unable to publish transaction", "err", err)
 			if sendState.ShouldAbortImmediately() {
-				cancel()
+				abort(ErrNonceTooLowAbort)
 			}
 			return
 		}
 
 		log.Debug("ContractsCaller transaction published successfully", "hash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap)
 
-		receipt, err := waitMined(
-			ctxc, m.backend, tx, m.cfg.ReceiptQueryInterval, m.cfg.NumConfirmations, sendState)
+		lastTxMu.Lock()
+		lastTx = tx
+		_, seen := published[txHash]
+		published[txHash] = struct{}{}
+		lastTxMu.Unlock()
+		if seen {
+			// 已有 goroutine 在等待这笔交易的回执
+			return
+		}
+
+		receipt, err := m.poller.waitMined(ctxc, txHash, m.cfg.NumConfirmations, sendState)
 		if err != nil {
 			log.Debug("ContractsCaller send tx failed", "hash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap, "err", err)
 		}
 		if receipt != nil {
 			select {
-			case receiptChan <- receipt:
+			case receiptChan <- minedTx{tx: tx, receipt: receipt}:
 				log.Trace("ContractsCaller send tx succeeded", "hash", txHash,
 					"nonce", nonce, "gasTipCap", gasTipCap,
 					"gasFeeCap", gasFeeCap)
@@ -110,29 +391,99 @@ func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPric
 			}
 		}
 	}
-	wg.Add(1)
-	go sendTxAsync()
+	// 安全模式下跳过本次发送，等块高恢复增长后再发送
+	publish := func() {
+		if m.head != nil && m.head.check(ctxc) {
+			log.Warn("ContractsCaller chain head stalled, skipping transaction publication")
+			return
+		}
+		wg.Add(1)
+		go sendTxAsync()
+	}
+	publish()
 
-	ticker := time.NewTicker(m.cfg.ResubmissionTimeout)
+	// 费用仍有竞争力时不加价，但重新广播在途交易，避免交易被丢弃或挤出交易池后不再发送
+	rebroadcast := func(tx *types.Transaction) {
+		if m.head != nil && m.head.check(ctxc) {
+			log.Warn("ContractsCaller chain head stalled, skipping transaction rebroadcast")
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := sendTx(ctxc, tx)
+			sendState.ProcessSendError(err)
+			if err == nil || errors.Is(err, context.Canceled) || strings.Contains(err.Error(), "context canceled") {
+				return
+			}
+			log.Debug("ContractsCaller unable to rebroadcast transaction", "hash", tx.Hash(), "err", err)
+			if sendState.ShouldAbortImmediately() {
+				abort(ErrNonceTooLowAbort)
+			}
+		}()
+	}
+
+	ticker := m.cfg.Clock.NewTicker(m.cfg.ResubmissionTimeout)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.Chan():
 			if sendState.IsWaitingForConfirmation() {
 				continue
 			}
-			wg.Add(1)
-			go sendTxAsync()
+			lastTxMu.Lock()
+			tx := lastTx
+			lastTxMu.Unlock()
+			if tx != nil && m.isFeeCompetitive(ctxc, tx) {
+				log.Debug("ContractsCaller transaction fees still competitive, rebroadcasting without bump", "hash", tx.Hash())
+				rebroadcast(tx)
+				continue
+			}
+			publish()
+		case <-m.ctx.Done():
+			abort(ErrClosed)
 		case <-ctxc.Done():
+			sendState.Abandon()
+			abortMu.Lock()
+			err := abortErr
+			abortMu.Unlock()
+			if err != nil {
+				return nil, err
+			}
 			return nil, ctxc.Err()
-		case receipt := <-receiptChan:
-			return receipt, nil
+		case mined := <-receiptChan:
+			lastTxMu.Lock()
+			attempts := uint64(len(published))
+			lastTxMu.Unlock()
+			wait := m.cfg.Clock.Now().Sub(start)
+			baseFee := m.inclusionBaseFee(ctx, mined.tx, mined.receipt)
+			return newSendResult(mined.tx, mined.receipt, baseFee, attempts, wait), nil
 		}
 	}
 
 }
 
+// feeQueryTimeout 加价前查询费用的超时时间，避免节点响应慢时阻塞 Send 的主循环
+const feeQueryTimeout = 5 * time.Second
+
+// isFeeCompetitive 在途交易的费用是否仍高于配置的分位，查询失败或超时时按需要加价处理
+func (m *SimpleTxManager) isFeeCompetitive(ctx context.Context, tx *types.Transaction) bool {
+	if m.cfg.BumpSkipPercentile <= 0 {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, feeQueryTimeout)
+	defer cancel()
+
+	baseFee, gasTipCap, err := m.cfg.FeeEstimator.CurrentFees(ctx, m.cfg.BumpSkipPercentile)
+	if err != nil {
+		log.Warn("ContractsCaller unable to fetch current fees", "err", err)
+		return false
+	}
+	return IsFeeCompetitive(tx, baseFee, gasTipCap)
+}
+
 func WaitMined(
 	ctx context.Context,
 	backend ReceiptSource,
@@ -140,7 +491,19 @@ func WaitMined(
 	queryInterval time.Duration,
 	numConfirmations uint64,
 ) (*types.Receipt, error) {
-	return waitMined(ctx, backend, tx, queryInterval, numConfirmations, nil)
+	return waitMined(ctx, backend, tx, SystemClock, queryInterval, numConfirmations, false, nil)
+}
+
+// WaitMinedWithClock 与 WaitMined 相同，查询间隔由 clock 计时，测试中可传入 txmgrtest.FakeClock
+func WaitMinedWithClock(
+	ctx context.Context,
+	backend ReceiptSource,
+	tx *types.Transaction,
+	clock Clock,
+	queryInterval time.Duration,
+	numConfirmations uint64,
+) (*types.Receipt, error) {
+	return waitMined(ctx, backend, tx, clock, queryInterval, numConfirmations, false, nil)
 }
 
 // waitMined 查询交易回执。可参考的定时器写法
@@ -148,61 +511,136 @@ func waitMined(
 	ctx context.Context,
 	backend ReceiptSource,
 	tx *types.Transaction,
+	clock Clock,
 	queryInterval time.Duration,
 	numConfirmations uint64,
+	waitForSafeHead bool,
 	sendState *SendState,
 ) (*types.Receipt, error) {
-	queryTicker := time.NewTicker(queryInterval)
+	queryTicker := clock.NewTicker(queryInterval)
 	defer queryTicker.Stop()
 
 	txHash := tx.Hash()
 
 	for {
-		receipt, err := backend.TransactionReceipt(ctx, txHash)
-		switch {
-		case receipt != nil:
-			if sendState != nil {
-				sendState.TxMined(txHash)
-			}
+		var update receiptUpdate
+		update.receipt, update.err = backend.TransactionReceipt(ctx, txHash)
+		if update.receipt != nil && numConfirmations > 0 {
+			update.tipHeight, update.tipErr = confirmationHeight(ctx, backend, waitForSafeHead) // 最新块高
+		}
+		if receipt := processReceipt(txHash, update, numConfirmations, sendState); receipt != nil {
+			return receipt, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-queryTicker.Chan():
+		}
+	}
+}
 
-			txHeight := receipt.BlockNumber.Uint64()   // 收据树的块高
-			tipHeight, err := backend.BlockNumber(ctx) // 最新块高
-			if err != nil {
-				log.Error("ContractsCaller Unable to fetch block number", "err", err)
-				break
-			}
+// receiptUpdate 一轮查询得到的交易回执和用于计算确认数的块高
+type receiptUpdate struct {
+	receipt   *types.Receipt
+	err       error
+	tipHeight uint64
+	tipErr    error
+	orphaned  bool  // 回执所在块已不在规范链上
+	headerErr error // 查询回执所在块的区块头失败
+}
+
+// processReceipt 根据一轮查询结果更新 sendState，交易达到确认数时返回回执
+func processReceipt(
+	txHash common.Hash,
+	update receiptUpdate,
+	numConfirmations uint64,
+	sendState *SendState,
+) *types.Receipt {
+	receipt := update.receipt
+	switch {
+	case receipt != nil && update.headerErr != nil:
+		log.Error("ContractsCaller Unable to fetch receipt block header", "hash", txHash, "err", update.headerErr)
+
+	case receipt != nil && update.orphaned:
+		// 回执所在块被重组掉，按未打包处理
+		log.Warn("ContractsCaller Receipt block no longer canonical", "hash", txHash,
+			"blockNumber", receipt.BlockNumber, "blockHash", receipt.BlockHash)
+		if sendState != nil {
+			sendState.TxNotMined(txHash)
+		}
 
-			log.Trace("ContractsCaller Transaction mined, checking confirmations",
-				"txHash", txHash, "txHeight", txHeight,
-				"tipHeight", tipHeight,
-				"numConfirmations", numConfirmations)
+	case receipt != nil:
+		if sendState != nil {
+			sendState.TxMined(txHash)
+		}
 
-			// 超过numConfirmations个块的确认后，即为确认
-			if txHeight+numConfirmations <= tipHeight+1 {
-				log.Debug("ContractsCaller Transaction confirmed", "txHash", txHash)
-				return receipt, nil
+		// 不需要确认数时，拿到回执即确认
+		if numConfirmations == 0 {
+			log.Debug("ContractsCaller Transaction confirmed", "txHash", txHash)
+			if sendState != nil {
+				sendState.TxConfirmed(receipt)
 			}
+			return receipt
+		}
 
-			confsRemaining := (txHeight + numConfirmations) - (tipHeight + 1)
-			log.Info("ContractsCaller Transaction not yet confirmed", "txHash", txHash,
-				"confsRemaining", confsRemaining)
-		case err != nil:
-			log.Trace("ContractsCaller Receipt retrieve failed", "hash", txHash,
-				"err", err)
+		txHeight := receipt.BlockNumber.Uint64() // 收据树的块高
+		tipHeight := update.tipHeight
+		if update.tipErr != nil {
+			log.Error("ContractsCaller Unable to fetch block number", "err", update.tipErr)
+			break
+		}
 
-		default:
-			// 收据树为空，且没有错误，则还没出块
+		log.Trace("ContractsCaller Transaction mined, checking confirmations",
+			"txHash", txHash, "txHeight", txHeight,
+			"tipHeight", tipHeight,
+			"numConfirmations", numConfirmations)
+
+		// 超过numConfirmations个块的确认后，即为确认
+		if txHeight+numConfirmations <= tipHeight+1 {
+			log.Debug("ContractsCaller Transaction confirmed", "txHash", txHash)
 			if sendState != nil {
-				sendState.TxNotMined(txHash)
+				sendState.TxConfirmed(receipt)
 			}
-			log.Trace("ContractsCaller Transaction not yet mined", "hash", txHash)
+			return receipt
 		}
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-queryTicker.C:
+
+		confsRemaining := (txHeight + numConfirmations) - (tipHeight + 1)
+		log.Info("ContractsCaller Transaction not yet confirmed", "txHash", txHash,
+			"confsRemaining", confsRemaining)
+	case update.err != nil && !errors.Is(update.err, ethereum.NotFound):
+		log.Trace("ContractsCaller Receipt retrieve failed", "hash", txHash,
+			"err", update.err)
+
+	default:
+		// 没有回执（节点返回空或 ethereum.NotFound），交易还没打包
+		if sendState != nil {
+			sendState.TxNotMined(txHash)
 		}
+		log.Trace("ContractsCaller Transaction not yet mined", "hash", txHash)
+	}
+	return nil
+}
+
+// checkCanonical 检查回执所在块是否仍在规范链上
+func checkCanonical(ctx context.Context, backend ReceiptSource, update *receiptUpdate) {
+	header, err := backend.(HeaderSource).HeaderByNumber(ctx, update.receipt.BlockNumber)
+	if err != nil {
+		update.headerErr = err
+		return
+	}
+	update.orphaned = header.Hash() != update.receipt.BlockHash
+}
+
+// confirmationHeight 返回用于计算确认数的块高，waitForSafeHead 时使用 safe 块高
+func confirmationHeight(ctx context.Context, backend ReceiptSource, waitForSafeHead bool) (uint64, error) {
+	if !waitForSafeHead {
+		return backend.BlockNumber(ctx)
+	}
+	header, err := backend.(HeaderSource).HeaderByNumber(ctx, big.NewInt(int64(rpc.SafeBlockNumber)))
+	if err != nil {
+		return 0, err
 	}
+	return header.Number.Uint64(), nil
 }
 
 func CalcGasFeeCap(baseFee, gasTipCap *big.Int) *big.Int {
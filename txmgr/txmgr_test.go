@@ -5,15 +5,19 @@ import (
 	"errors"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/DQYXACML/dapplink-vrf/txmgr"
+	"github.com/DQYXACML/dapplink-vrf/txmgr/txmgrtest"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type testHarness struct {
@@ -23,9 +27,15 @@ type testHarness struct {
 	gasPricer *gasPricer
 }
 
-func newTestHarnessWithConfig(cfg txmgr.Config) *testHarness {
+func newTxManager(t testing.TB, cfg txmgr.Config, backend txmgr.ReceiptSource) *txmgr.SimpleTxManager {
+	mgr, err := txmgr.NewSimpleTxManager(cfg, backend)
+	require.Nil(t, err)
+	return mgr
+}
+
+func newTestHarnessWithConfig(t testing.TB, cfg txmgr.Config) *testHarness {
 	backend := newMockBackend()
-	mgr := txmgr.NewSimpleTxManager(cfg, backend)
+	mgr := newTxManager(t, cfg, backend)
 
 	return &testHarness{
 		cfg:       cfg,
@@ -35,8 +45,8 @@ func newTestHarnessWithConfig(cfg txmgr.Config) *testHarness {
 	}
 }
 
-func newTestHarness() *testHarness {
-	return newTestHarnessWithConfig(configWithNumConfs(1))
+func newTestHarness(t testing.TB) *testHarness {
+	return newTestHarnessWithConfig(t, configWithNumConfs(1))
 }
 
 func configWithNumConfs(numConfirmations uint64) txmgr.Config {
@@ -153,7 +163,7 @@ func (b *mockBackend) TransactionReceipt(
 func TestTxMgrConfirmAtMinGasPrice(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	h := newTestHarness(t)
 
 	gasPricer := newGasPricer(1)
 
@@ -174,16 +184,176 @@ func TestTxMgrConfirmAtMinGasPrice(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
+}
+
+func TestTxMgrExposesSendState(t *testing.T) {
+	t.Parallel()
+
+	backend := txmgrtest.NewFakeReceiptSource()
+	sender := txmgrtest.NewFakeSender(backend, true)
+	mgr := newTxManager(t, configWithNumConfs(1), backend)
+
+	var (
+		mu          sync.Mutex
+		sendState   *txmgr.SendState
+		transitions []txmgr.TxState
+	)
+	ctx := txmgr.WithSendStateObserver(context.Background(), func(s *txmgr.SendState) {
+		sendState = s
+		s.OnTransition(func(from, to txmgr.TxState) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			transitions = append(transitions, to)
+		})
+	})
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+		}), nil
+	}
+
+	result, err := mgr.Send(ctx, updateGasPrice, sender.Send)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.NotNil(t, sendState)
+	require.Equal(t, txmgr.TxStateConfirmed, sendState.State())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []txmgr.TxState{
+		txmgr.TxStateSubmitted,
+		txmgr.TxStateMined,
+		txmgr.TxStateConfirmed,
+	}, transitions)
+}
+
+// effectivePriceBackend 在回执中返回固定的 effectiveGasPrice
+type effectivePriceBackend struct {
+	*mockBackend
+
+	price *big.Int
+}
+
+func (b *effectivePriceBackend) TransactionReceipt(
+	ctx context.Context,
+	txHash common.Hash,
+) (*types.Receipt, error) {
+
+	receipt, err := b.mockBackend.TransactionReceipt(ctx, txHash)
+	if receipt == nil || err != nil {
+		return receipt, err
+	}
+	receipt.EffectiveGasPrice = b.price
+	return receipt, nil
+}
+
+func TestTxMgrSendResultCostBreakdown(t *testing.T) {
+	t.Parallel()
+
+	backend := &effectivePriceBackend{mockBackend: newMockBackend(), price: big.NewInt(7)}
+	mgr := newTxManager(t, configWithNumConfs(1), backend)
+	gasPricer := newGasPricer(2)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	start := time.Now()
+	result, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, uint64(2), result.Attempts)
+	require.Equal(t, big.NewInt(7), result.EffectiveGasPrice)
+	gasUsed := new(big.Int).SetUint64(result.Receipt.GasUsed)
+	require.Equal(t, new(big.Int).Mul(gasUsed, big.NewInt(7)), result.FeePaid)
+	require.Greater(t, result.WaitDuration, time.Duration(0))
+	require.LessOrEqual(t, result.WaitDuration, time.Since(start))
+}
+
+func TestTxMgrCountsRepublishedTxOnce(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+
+	// 每次构建都返回同一笔交易，例如加价阶梯已经用完
+	tx := types.NewTx(&types.DynamicFeeTx{
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+	})
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return tx, nil
+	}
+
+	var sends atomic.Int64
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if sends.Add(1) == 2 {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	result, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.Equal(t, int64(2), sends.Load())
+	require.Equal(t, uint64(1), result.Attempts)
+	require.Equal(t, tx.Hash(), result.Receipt.TxHash)
+}
+
+func TestLegacyTxManager(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	mgr := txmgr.NewLegacyTxManager(h.mgr)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	receipt, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
 	require.Nil(t, err)
 	require.NotNil(t, receipt)
-	require.Equal(t, gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	receipt, err = mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Equal(t, context.Canceled, err)
+	require.Nil(t, receipt)
 }
 
 func TestTxMgrNeverConfirmCancel(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	h := newTestHarness(t)
 
 	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
 		gasTipCap, gasFeeCap := h.gasPricer.sample()
@@ -201,15 +371,15 @@ func TestTxMgrNeverConfirmCancel(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Equal(t, err, context.DeadlineExceeded)
-	require.Nil(t, receipt)
+	require.Nil(t, result)
 }
 
 func TestTxMgrConfirmsAtHigherGasPrice(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	h := newTestHarness(t)
 
 	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
 		gasTipCap, gasFeeCap := h.gasPricer.sample()
@@ -228,10 +398,10 @@ func TestTxMgrConfirmsAtHigherGasPrice(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Nil(t, err)
-	require.NotNil(t, receipt)
-	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
+	require.NotNil(t, result)
+	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
 }
 
 var errRpcFailure = errors.New("rpc failure")
@@ -239,7 +409,7 @@ var errRpcFailure = errors.New("rpc failure")
 func TestTxMgrBlocksOnFailingRpcCalls(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	h := newTestHarness(t)
 
 	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
 		gasTipCap, gasFeeCap := h.gasPricer.sample()
@@ -256,15 +426,62 @@ func TestTxMgrBlocksOnFailingRpcCalls(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Equal(t, err, context.DeadlineExceeded)
-	require.Nil(t, receipt)
+	require.Nil(t, result)
+}
+
+func TestTxMgrAbortsOnRepeatedNonceTooLow(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return core.ErrNonceTooLow
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.ErrorIs(t, err, txmgr.ErrNonceTooLowAbort)
+	require.Nil(t, result)
+}
+
+func TestTxMgrAbortsOnUpdateGasPriceFailure(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return nil, errRpcFailure
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.ErrorIs(t, err, txmgr.ErrUpdateGasPrice)
+	require.ErrorIs(t, err, errRpcFailure)
+	require.Nil(t, result)
 }
 
 func TestTxMgrOnlyOnePublicationSucceeds(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	h := newTestHarness(t)
 
 	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
 		gasTipCap, gasFeeCap := h.gasPricer.sample()
@@ -286,17 +503,17 @@ func TestTxMgrOnlyOnePublicationSucceeds(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Nil(t, err)
 
-	require.NotNil(t, receipt)
-	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
+	require.NotNil(t, result)
+	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
 }
 
 func TestTxMgrConfirmsMinGasPriceAfterBumping(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	h := newTestHarness(t)
 
 	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
 		gasTipCap, gasFeeCap := h.gasPricer.sample()
@@ -318,16 +535,16 @@ func TestTxMgrConfirmsMinGasPriceAfterBumping(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Nil(t, err)
-	require.NotNil(t, receipt)
-	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
+	require.NotNil(t, result)
+	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
 }
 
 func TestTxMgrDoesntAbortNonceTooLowAfterMiningTx(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarnessWithConfig(configWithNumConfs(2))
+	h := newTestHarnessWithConfig(t, configWithNumConfs(2))
 
 	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
 		gasTipCap, gasFeeCap := h.gasPricer.sample()
@@ -357,125 +574,1092 @@ func TestTxMgrDoesntAbortNonceTooLowAfterMiningTx(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Nil(t, err)
-	require.NotNil(t, receipt)
-	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
+	require.NotNil(t, result)
+	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
 }
 
-func TestWaitMinedReturnsReceiptOnFirstSuccess(t *testing.T) {
+func TestTxMgrConfirmsWithSubmissionDelay(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	cfg := configWithNumConfs(1)
+	cfg.MaxSubmissionDelay = 200 * time.Millisecond
+	h := newTestHarnessWithConfig(t, cfg)
 
-	// Create a tx and mine it immediately using the default backend.
-	tx := types.NewTx(&types.LegacyTx{})
-	txHash := tx.Hash()
-	h.backend.mine(&txHash, new(big.Int))
+	gasPricer := newGasPricer(1)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
 
 	ctx := context.Background()
-	receipt, err := txmgr.WaitMined(ctx, h.backend, tx, 50*time.Millisecond, 1)
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Nil(t, err)
-	require.NotNil(t, receipt)
-	require.Equal(t, receipt.TxHash, txHash)
+	require.NotNil(t, result)
+	require.Equal(t, gasPricer.expGasFeeCap().Uint64(), result.Receipt.GasUsed)
 }
 
-func TestWaitMinedCanBeCanceled(t *testing.T) {
+func TestTxMgrSubmissionDelayCanBeCanceled(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	cfg := configWithNumConfs(1)
+	cfg.MaxSubmissionDelay = time.Hour
+	h := newTestHarnessWithConfig(t, cfg)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		t.Error("transaction should not be built while delayed")
+		return nil, nil
+	}
 
-	// Create an unimined tx.
-	tx := types.NewTx(&types.LegacyTx{})
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
 
-	receipt, err := txmgr.WaitMined(ctx, h.backend, tx, 50*time.Millisecond, 1)
-	require.Equal(t, err, context.DeadlineExceeded)
-	require.Nil(t, receipt)
+	// 使用没有截止时间的 ctx，否则等待时长会被截到剩余时间的一半
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Equal(t, err, context.Canceled)
+	require.Nil(t, result)
 }
 
-func TestWaitMinedMultipleConfs(t *testing.T) {
+func TestTxMgrSubmissionDelayClippedToDeadline(t *testing.T) {
 	t.Parallel()
 
-	const numConfs = 2
-
-	h := newTestHarnessWithConfig(configWithNumConfs(numConfs))
-	ctxt, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-
-	// Create an unimined tx.
-	tx := types.NewTx(&types.LegacyTx{})
-	txHash := tx.Hash()
-	h.backend.mine(&txHash, new(big.Int))
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	cfg := configWithNumConfs(1)
+	cfg.MaxSubmissionDelay = time.Hour
+	cfg.Clock = clock
+	backend := txmgrtest.NewFakeReceiptSource()
+	sender := txmgrtest.NewFakeSender(backend, true)
+	mgr := newTxManager(t, cfg, backend)
 
-	receipt, err := txmgr.WaitMined(ctxt, h.backend, tx, 50*time.Millisecond, numConfs)
-	require.Equal(t, err, context.DeadlineExceeded)
-	require.Nil(t, receipt)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(20),
+		}), nil
+	}
 
-	ctxt, cancel = context.WithTimeout(context.Background(), time.Second)
+	// 截止时间在 10 分钟后，等待时长不超过 5 分钟
+	ctx, cancel := txmgr.WithClockDeadline(context.Background(), clock,
+		clock.Now().Add(10*time.Minute), context.DeadlineExceeded)
 	defer cancel()
 
-	// Mine an empty block, tx should now be confirmed.
-	h.backend.mine(nil, nil)
-	receipt, err = txmgr.WaitMined(ctxt, h.backend, tx, 50*time.Millisecond, numConfs)
-	require.Nil(t, err)
-	require.NotNil(t, receipt)
-	require.Equal(t, txHash, receipt.TxHash)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := mgr.Send(ctx, updateGasPrice, sender.Send)
+		errc <- err
+	}()
+
+	// 截止时间的定时器和推迟发送的定时器
+	clock.BlockUntil(2)
+	require.Empty(t, sender.Sent())
+	clock.Advance(5 * time.Minute)
+	require.Eventually(t, func() bool {
+		return len(sender.Sent()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 回执查询由 FakeClock 驱动，推进时钟直到确认
+	for {
+		select {
+		case err := <-errc:
+			require.Nil(t, err)
+			return
+		case <-time.After(10 * time.Millisecond):
+			clock.Advance(cfg.ReceiptQueryInterval)
+		}
+	}
 }
 
-func TestManagerPanicOnZeroConfs(t *testing.T) {
+func TestTxMgrQueuesSendsBeyondMaxInFlight(t *testing.T) {
 	t.Parallel()
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Fatal("NewSimpleTxManager should panic when using zero conf")
+	cfg := configWithNumConfs(1)
+	cfg.MaxInFlight = 1
+	h := newTestHarnessWithConfig(t, cfg)
+
+	started := make(chan struct{}, 1)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		select {
+		case started <- struct{}{}:
+		default:
 		}
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		// Never mine, keeping the first Send in flight.
+		return nil
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := h.mgr.Send(ctx1, updateGasPrice, sendTx)
+		errc <- err
 	}()
+	<-started
+
+	queuedUpdateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		t.Error("queued Send should not build a transaction")
+		return nil, errRpcFailure
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel2()
+
+	result, err := h.mgr.Send(ctx2, queuedUpdateGasPrice, sendTx)
+	require.Equal(t, err, context.DeadlineExceeded)
+	require.Nil(t, result)
 
-	_ = newTestHarnessWithConfig(configWithNumConfs(0))
+	cancel1()
+	require.Equal(t, context.Canceled, <-errc)
 }
 
-type failingBackend struct {
-	returnSuccessBlockNumber bool
-	returnSuccessReceipt     bool
+// gasUsedBackend 在回执中填入固定 gasUsed、不返回 effectiveGasPrice 的 FakeReceiptSource
+type gasUsedBackend struct {
+	*txmgrtest.FakeReceiptSource
 }
 
-func (b *failingBackend) BlockNumber(ctx context.Context) (uint64, error) {
-	if !b.returnSuccessBlockNumber {
-		b.returnSuccessBlockNumber = true
-		return 0, errRpcFailure
+func (b *gasUsedBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.FakeReceiptSource.TransactionReceipt(ctx, txHash)
+	if receipt != nil {
+		receipt.GasUsed = 21000
 	}
+	return receipt, err
+}
 
-	return 1, nil
+func TestTxMgrSendResultComputesDynamicFeePrice(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		gasTipCap, gasFeeCap, effective int64
+	}{
+		{gasTipCap: 5, gasFeeCap: 20, effective: 12}, // baseFee + tipCap
+		{gasTipCap: 5, gasFeeCap: 10, effective: 10}, // 受 feeCap 限制
+	} {
+		backend := &gasUsedBackend{FakeReceiptSource: txmgrtest.NewFakeReceiptSource()}
+		backend.SetBaseFee(big.NewInt(7))
+		sender := txmgrtest.NewFakeSender(backend.FakeReceiptSource, true)
+		mgr := newTxManager(t, configWithNumConfs(1), backend)
+
+		updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+			return types.NewTx(&types.DynamicFeeTx{
+				GasTipCap: big.NewInt(tc.gasTipCap),
+				GasFeeCap: big.NewInt(tc.gasFeeCap),
+			}), nil
+		}
+		result, err := mgr.Send(context.Background(), updateGasPrice, sender.Send)
+		require.Nil(t, err)
+		require.Nil(t, result.Receipt.EffectiveGasPrice)
+		require.Equal(t, big.NewInt(tc.effective), result.EffectiveGasPrice)
+		require.Equal(t, big.NewInt(tc.effective*21000), result.FeePaid)
+	}
 }
 
-func (b *failingBackend) TransactionReceipt(
-	ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+func TestTxMgrSendResultWithoutHeaders(t *testing.T) {
+	t.Parallel()
 
-	if !b.returnSuccessReceipt {
-		b.returnSuccessReceipt = true
-		return nil, errRpcFailure
+	h := newTestHarness(t)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(20),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
 	}
 
-	return &types.Receipt{
-		TxHash:      txHash,
-		BlockNumber: big.NewInt(1),
-	}, nil
+	// 无法得知所在块的 baseFee，不用 feeCap 冒充实际价格
+	result, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.Nil(t, result.EffectiveGasPrice)
+	require.Nil(t, result.FeePaid)
 }
 
-func TestWaitMinedReturnsReceiptAfterFailure(t *testing.T) {
-	t.Parallel()
+// nonceBackend 返回固定 nonce 的 mockBackend
+type nonceBackend struct {
+	*mockBackend
 
-	var borkedBackend failingBackend
+	latest  uint64
+	pending uint64
+}
 
-	tx := types.NewTx(&types.LegacyTx{})
-	txHash := tx.Hash()
+func (b *nonceBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return b.latest, nil
+}
 
-	ctx := context.Background()
-	receipt, err := txmgr.WaitMined(ctx, &borkedBackend, tx, 50*time.Millisecond, 1)
-	require.Nil(t, err)
-	require.NotNil(t, receipt)
-	require.Equal(t, receipt.TxHash, txHash)
+func (b *nonceBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.pending, nil
+}
+
+func TestTxMgrBackpressurePendingSends(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.MaxInFlight = 1
+	cfg.MaxPendingSends = 2
+	h := newTestHarnessWithConfig(t, cfg)
+	mgr := h.mgr.(*txmgr.SimpleTxManager)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		// Never mine, keeping the Sends outstanding.
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.mgr.Send(ctx, updateGasPrice, sendTx)
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return mgr.PendingSends() == 2
+	}, time.Second, 10*time.Millisecond)
+	bp, err := mgr.Backpressure(context.Background())
+	require.Nil(t, err)
+	require.True(t, bp.Throttle)
+	require.Equal(t, uint64(2), bp.PendingSends)
+
+	cancel()
+	wg.Wait()
+	bp, err = mgr.Backpressure(context.Background())
+	require.Nil(t, err)
+	require.False(t, bp.Throttle)
+	require.Equal(t, uint64(0), bp.PendingSends)
+}
+
+func TestTxMgrBackpressureNonceSpan(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.MaxNonceSpan = 3
+	cfg.Sender = common.HexToAddress("0x01")
+	backend := &nonceBackend{mockBackend: newMockBackend(), latest: 10, pending: 12}
+	mgr := newTxManager(t, cfg, backend)
+
+	bp, err := mgr.Backpressure(context.Background())
+	require.Nil(t, err)
+	require.False(t, bp.Throttle)
+	require.Equal(t, uint64(2), bp.NonceSpan)
+
+	backend.pending = 13
+	bp, err = mgr.Backpressure(context.Background())
+	require.Nil(t, err)
+	require.True(t, bp.Throttle)
+	require.Equal(t, uint64(3), bp.NonceSpan)
+}
+
+func TestTxMgrBackpressureRequiresNonceSource(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.MaxNonceSpan = 3
+	cfg.Sender = common.HexToAddress("0x01")
+	_, err := txmgr.NewSimpleTxManager(cfg, newMockBackend())
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+
+	cfg.Sender = common.Address{}
+	_, err = txmgr.NewSimpleTxManager(cfg, &nonceBackend{mockBackend: newMockBackend()})
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
+
+func TestTxMgrConfirmsUnderInjectedFaults(t *testing.T) {
+	t.Parallel()
+
+	injector := txmgrtest.NewInjector(txmgrtest.Faults{
+		RPCTimeout:  0.3,
+		Reorg:       0.2,
+		DropTx:      0.3,
+		NonceTooLow: 0.2,
+		Seed:        1,
+	})
+
+	backend := newMockBackend()
+	cfg := configWithNumConfs(1)
+	cfg.ResubmissionTimeout = 100 * time.Millisecond
+	cfg.SafeAbortNonceTooLowCount = 100
+	mgr := newTxManager(t, cfg, injector.WrapBackend(backend))
+
+	gasPricer := newGasPricer(3)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := mgr.Send(ctx, updateGasPrice, injector.WrapSend(sendTx))
+	require.Nil(t, err)
+	require.NotNil(t, result)
+}
+
+// fullFakeBackend 额外实现 FeeHistoryReader 和 NonceSource 的 FakeReceiptSource
+type fullFakeBackend struct {
+	*txmgrtest.FakeReceiptSource
+}
+
+func (b *fullFakeBackend) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+
+	return &ethereum.FeeHistory{
+		Reward:  [][]*big.Int{{big.NewInt(1)}},
+		BaseFee: []*big.Int{big.NewInt(1), big.NewInt(1)},
+	}, nil
+}
+
+func (b *fullFakeBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return 4, nil
+}
+
+func (b *fullFakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 5, nil
+}
+
+func TestInjectorWrapBackendForwardsOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	injector := txmgrtest.NewInjector(txmgrtest.Faults{Seed: 1})
+	wrapped := injector.WrapBackend(newMockBackend())
+	_, ok := wrapped.(txmgr.HeaderSource)
+	require.False(t, ok)
+	_, ok = wrapped.(txmgr.FeeHistoryReader)
+	require.False(t, ok)
+	_, ok = wrapped.(txmgr.NonceSource)
+	require.False(t, ok)
+
+	wrapped = injector.WrapBackend(&fullFakeBackend{FakeReceiptSource: txmgrtest.NewFakeReceiptSource()})
+	_, ok = wrapped.(txmgr.HeaderSource)
+	require.True(t, ok)
+	_, ok = wrapped.(txmgr.FeeHistoryReader)
+	require.True(t, ok)
+	_, ok = wrapped.(txmgr.NonceSource)
+	require.True(t, ok)
+	_, ok = wrapped.(txmgr.BatchReceiptSource)
+	require.True(t, ok)
+}
+
+func TestTxMgrConfirmsOnWrappedFullBackend(t *testing.T) {
+	t.Parallel()
+
+	injector := txmgrtest.NewInjector(txmgrtest.Faults{
+		RPCTimeout: 0.2,
+		Reorg:      0.2,
+		Seed:       1,
+	})
+
+	backend := &fullFakeBackend{FakeReceiptSource: txmgrtest.NewFakeReceiptSource()}
+	sender := txmgrtest.NewFakeSender(backend.FakeReceiptSource, true)
+	cfg := configWithNumConfs(2)
+	cfg.ResubmissionTimeout = 100 * time.Millisecond
+	cfg.VerifyReceiptBlockHash = true
+	cfg.WaitForSafeHead = true
+	cfg.BumpSkipPercentile = 50
+	cfg.MaxNonceSpan = 3
+	cfg.Sender = common.HexToAddress("0x01")
+	mgr := newTxManager(t, cfg, injector.WrapBackend(backend))
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(20),
+		}), nil
+	}
+	// 持续出块，使交易能够达到确认数
+	go func() {
+		for range 40 {
+			time.Sleep(50 * time.Millisecond)
+			backend.Mine()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := mgr.Send(ctx, updateGasPrice, sender.Send)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+
+	// 注入的超时可能命中，重试直到拿到 nonce
+	require.Eventually(t, func() bool {
+		bp, err := mgr.Backpressure(ctx)
+		return err == nil && bp.NonceSpan == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// blockingReceiptBackend 查询回执时阻塞到 ctx 结束，记录查询是否已退出
+type blockingReceiptBackend struct {
+	*mockBackend
+
+	started chan struct{}
+	exited  chan struct{}
+	once    sync.Once
+}
+
+func (b *blockingReceiptBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.once.Do(func() { close(b.started) })
+	<-ctx.Done()
+	defer func() {
+		select {
+		case b.exited <- struct{}{}:
+		default:
+		}
+	}()
+	return nil, ctx.Err()
+}
+
+func TestTxMgrCloseStopsReceiptPoller(t *testing.T) {
+	t.Parallel()
+
+	backend := &blockingReceiptBackend{
+		mockBackend: newMockBackend(),
+		started:     make(chan struct{}),
+		exited:      make(chan struct{}, 1),
+	}
+	mgr := newTxManager(t, configWithNumConfs(1), backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(20),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+		errc <- err
+	}()
+	<-backend.started
+
+	// Close 返回时查询循环已经退出，阻塞中的回执查询随之取消
+	closed := make(chan struct{})
+	go func() {
+		mgr.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not wait for the receipt poller to exit")
+	}
+	select {
+	case <-backend.exited:
+	default:
+		t.Fatal("in-flight receipt query was not canceled")
+	}
+	require.ErrorIs(t, <-errc, txmgr.ErrClosed)
+
+	_, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.ErrorIs(t, err, txmgr.ErrClosed)
+}
+
+func TestTxMgrPausesSendsWhileHeadStalled(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.MaxHeadStall = 300 * time.Millisecond
+	h := newTestHarnessWithConfig(t, cfg)
+	mgr := h.mgr.(*txmgr.SimpleTxManager)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	var numSends atomic.Int64
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		numSends.Add(1)
+		return nil
+	}
+
+	// The head doesn't move until 2.2s in, so the bumps at 1s and 2s are
+	// skipped and only the one at 3s is published.
+	time.AfterFunc(2200*time.Millisecond, func() {
+		h.backend.mine(nil, nil)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3500*time.Millisecond)
+	defer cancel()
+
+	var safeModeWhileStalled atomic.Bool
+	time.AfterFunc(1500*time.Millisecond, func() {
+		safeModeWhileStalled.Store(mgr.InSafeMode())
+	})
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Equal(t, err, context.DeadlineExceeded)
+	require.Nil(t, result)
+	require.Equal(t, int64(2), numSends.Load())
+	require.True(t, safeModeWhileStalled.Load())
+	require.False(t, mgr.InSafeMode())
+}
+
+func TestTxMgrBumpsDeterministicallyWithFakeClock(t *testing.T) {
+	t.Parallel()
+
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+	backend := txmgrtest.NewFakeReceiptSource()
+	sender := txmgrtest.NewFakeSender(backend, false)
+
+	cfg := configWithNumConfs(1)
+	cfg.Clock = clock
+	mgr := newTxManager(t, cfg, backend)
+
+	gasPricer := newGasPricer(3)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if err := sender.Send(ctx, tx); err != nil {
+			return err
+		}
+		if gasPricer.shouldMine(tx.GasFeeCap()) {
+			backend.Mine(tx.Hash())
+		}
+		return nil
+	}
+
+	results := make(chan *txmgr.SendResult, 1)
+	go func() {
+		result, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+		if err != nil {
+			t.Error(err)
+		}
+		results <- result
+	}()
+
+	// Wait for the resubmission ticker and the receipt poller's ticker.
+	clock.BlockUntil(2)
+	for numSent := 1; numSent < 3; numSent++ {
+		require.Eventually(t, func() bool {
+			return len(sender.Sent()) == numSent
+		}, time.Second, time.Millisecond)
+		clock.Advance(cfg.ResubmissionTimeout)
+	}
+
+	var result *txmgr.SendResult
+	require.Eventually(t, func() bool {
+		clock.Advance(cfg.ReceiptQueryInterval)
+		select {
+		case result = <-results:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+
+	sent := sender.Sent()
+	require.Len(t, sent, 3)
+	require.NotNil(t, result)
+	require.Equal(t, sent[2].Hash(), result.Receipt.TxHash)
+	require.Equal(t, uint64(3), result.Attempts)
+	require.GreaterOrEqual(t, result.WaitDuration, 2*cfg.ResubmissionTimeout)
+}
+
+func TestTxMgrIgnoresOrphanedReceipts(t *testing.T) {
+	t.Parallel()
+
+	backend := txmgrtest.NewFakeReceiptSource()
+
+	cfg := configWithNumConfs(1)
+	cfg.VerifyReceiptBlockHash = true
+	mgr := newTxManager(t, cfg, backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+		}), nil
+	}
+
+	var once sync.Once
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		once.Do(func() {
+			// The node keeps serving the receipt from a block that was
+			// reorged out, and the tx is only re-included later.
+			txHash := tx.Hash()
+			orphanedBlock := backend.Mine(txHash)
+			backend.ReorgBlock(orphanedBlock)
+			time.AfterFunc(300*time.Millisecond, func() {
+				backend.Mine(txHash)
+			})
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, uint64(2), result.Receipt.BlockNumber.Uint64())
+}
+
+func TestManagerErrorOnVerifyBlockHashWithoutHeaderSource(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.VerifyReceiptBlockHash = true
+	_, err := txmgr.NewSimpleTxManager(cfg, newMockBackend())
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
+
+func TestWaitMinedReturnsReceiptOnFirstSuccess(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+
+	// Create a tx and mine it immediately using the default backend.
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+	h.backend.mine(&txHash, new(big.Int))
+
+	ctx := context.Background()
+	receipt, err := txmgr.WaitMined(ctx, h.backend, tx, 50*time.Millisecond, 1)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, receipt.TxHash, txHash)
+}
+
+func TestWaitMinedCanBeCanceled(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Create an unimined tx.
+	tx := types.NewTx(&types.LegacyTx{})
+
+	receipt, err := txmgr.WaitMined(ctx, h.backend, tx, 50*time.Millisecond, 1)
+	require.Equal(t, err, context.DeadlineExceeded)
+	require.Nil(t, receipt)
+}
+
+func TestWaitMinedMultipleConfs(t *testing.T) {
+	t.Parallel()
+
+	const numConfs = 2
+
+	h := newTestHarnessWithConfig(t, configWithNumConfs(numConfs))
+	ctxt, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Create an unimined tx.
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+	h.backend.mine(&txHash, new(big.Int))
+
+	receipt, err := txmgr.WaitMined(ctxt, h.backend, tx, 50*time.Millisecond, numConfs)
+	require.Equal(t, err, context.DeadlineExceeded)
+	require.Nil(t, receipt)
+
+	ctxt, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Mine an empty block, tx should now be confirmed.
+	h.backend.mine(nil, nil)
+	receipt, err = txmgr.WaitMined(ctxt, h.backend, tx, 50*time.Millisecond, numConfs)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, txHash, receipt.TxHash)
+}
+
+func TestWaitMinedWithClock(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	clock := txmgrtest.NewFakeClock(time.Unix(0, 0))
+
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+
+	type result struct {
+		receipt *types.Receipt
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		receipt, err := txmgr.WaitMinedWithClock(context.Background(), h.backend, tx, clock, time.Minute, 1)
+		done <- result{receipt, err}
+	}()
+
+	// 第一次查询未打包，之后只在时钟推进时查询
+	clock.BlockUntil(1)
+	h.backend.mine(&txHash, new(big.Int))
+	select {
+	case <-done:
+		t.Fatal("receipt returned before the query interval elapsed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clock.Advance(time.Minute)
+	res := <-done
+	require.Nil(t, res.err)
+	require.Equal(t, txHash, res.receipt.TxHash)
+}
+
+func TestManagerErrorOnInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	for name, mutate := range map[string]func(cfg *txmgr.Config){
+		"zero resubmission timeout": func(cfg *txmgr.Config) { cfg.ResubmissionTimeout = 0 },
+		"zero query interval":       func(cfg *txmgr.Config) { cfg.ReceiptQueryInterval = 0 },
+		"zero nonce too low count":  func(cfg *txmgr.Config) { cfg.SafeAbortNonceTooLowCount = 0 },
+		"percentile out of range":   func(cfg *txmgr.Config) { cfg.BumpSkipPercentile = 101 },
+		"zero confs with safe head": func(cfg *txmgr.Config) {
+			cfg.NumConfirmations = 0
+			cfg.WaitForSafeHead = true
+		},
+	} {
+		cfg := configWithNumConfs(1)
+		mutate(&cfg)
+		_, err := txmgr.NewSimpleTxManager(cfg, txmgrtest.NewFakeReceiptSource())
+		require.ErrorIs(t, err, txmgr.ErrInvalidConfig, name)
+	}
+}
+
+func TestTxMgrZeroConfsConfirmsOnReceipt(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarnessWithConfig(t, configWithNumConfs(0))
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+}
+
+func TestWaitMinedZeroConfsSkipsBlockNumber(t *testing.T) {
+	t.Parallel()
+
+	// The backend fails every BlockNumber call until the first success; a
+	// zero-conf wait must not depend on it.
+	borkedBackend := failingBackend{returnSuccessReceipt: true}
+
+	tx := types.NewTx(&types.LegacyTx{})
+
+	receipt, err := txmgr.WaitMined(context.Background(), &borkedBackend, tx, 50*time.Millisecond, 0)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.False(t, borkedBackend.returnSuccessBlockNumber)
+}
+
+type safeHeadBackend struct {
+	*mockBackend
+
+	safeHeight uint64
+}
+
+func (b *safeHeadBackend) setSafeHeight(height uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.safeHeight = height
+}
+
+func (b *safeHeadBackend) HeaderByNumber(
+	ctx context.Context,
+	number *big.Int,
+) (*types.Header, error) {
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if number == nil || number.Int64() != int64(rpc.SafeBlockNumber) {
+		return nil, errRpcFailure
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(b.safeHeight)}, nil
+}
+
+func TestTxMgrWaitsForSafeHead(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.WaitForSafeHead = true
+	backend := &safeHeadBackend{mockBackend: newMockBackend()}
+	mgr := newTxManager(t, cfg, backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	// The tx is mined on the unsafe head immediately, but the safe head only
+	// catches up later.
+	const safeDelay = 500 * time.Millisecond
+	time.AfterFunc(safeDelay, func() {
+		backend.setSafeHeight(1)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	result, err := mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, result)
+	require.GreaterOrEqual(t, time.Since(start), safeDelay)
+}
+
+func TestManagerErrorOnSafeHeadWithoutHeaderSource(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.WaitForSafeHead = true
+	_, err := txmgr.NewSimpleTxManager(cfg, newMockBackend())
+	require.ErrorIs(t, err, txmgr.ErrInvalidConfig)
+}
+
+type batchBackend struct {
+	*mockBackend
+
+	singleCalls atomic.Int64
+	batchCalls  atomic.Int64
+}
+
+func (b *batchBackend) TransactionReceipt(
+	ctx context.Context,
+	txHash common.Hash,
+) (*types.Receipt, error) {
+
+	b.singleCalls.Add(1)
+	return b.mockBackend.TransactionReceipt(ctx, txHash)
+}
+
+func (b *batchBackend) TransactionReceipts(
+	ctx context.Context,
+	txHashes []common.Hash,
+) ([]*types.Receipt, []error) {
+
+	b.batchCalls.Add(1)
+	receipts := make([]*types.Receipt, len(txHashes))
+	errs := make([]error, len(txHashes))
+	for i, txHash := range txHashes {
+		receipts[i], errs[i] = b.mockBackend.TransactionReceipt(ctx, txHash)
+	}
+	return receipts, errs
+}
+
+func TestTxMgrPollsReceiptsInBatches(t *testing.T) {
+	t.Parallel()
+
+	backend := &batchBackend{mockBackend: newMockBackend()}
+	mgr := newTxManager(t, configWithNumConfs(1), backend)
+
+	const numSends = 5
+
+	var wg sync.WaitGroup
+	errs := make(chan error, numSends)
+	for i := 0; i < numSends; i++ {
+		nonce := uint64(i)
+		updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+			return types.NewTx(&types.DynamicFeeTx{
+				Nonce:     nonce,
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(10),
+			}), nil
+		}
+
+		sendTx := func(ctx context.Context, tx *types.Transaction) error {
+			txHash := tx.Hash()
+			time.AfterFunc(200*time.Millisecond, func() {
+				backend.mine(&txHash, tx.GasFeeCap())
+			})
+			return nil
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mgr.Send(context.Background(), updateGasPrice, sendTx)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.Nil(t, err)
+	}
+	require.Zero(t, backend.singleCalls.Load())
+	require.NotZero(t, backend.batchCalls.Load())
+}
+
+type failingBackend struct {
+	returnSuccessBlockNumber bool
+	returnSuccessReceipt     bool
+}
+
+func (b *failingBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	if !b.returnSuccessBlockNumber {
+		b.returnSuccessBlockNumber = true
+		return 0, errRpcFailure
+	}
+
+	return 1, nil
+}
+
+func (b *failingBackend) TransactionReceipt(
+	ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+
+	if !b.returnSuccessReceipt {
+		b.returnSuccessReceipt = true
+		return nil, errRpcFailure
+	}
+
+	return &types.Receipt{
+		TxHash:      txHash,
+		BlockNumber: big.NewInt(1),
+	}, nil
+}
+
+func TestWaitMinedReturnsReceiptAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	var borkedBackend failingBackend
+
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+
+	ctx := context.Background()
+	receipt, err := txmgr.WaitMined(ctx, &borkedBackend, tx, 50*time.Millisecond, 1)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, receipt.TxHash, txHash)
+}
+
+func BenchmarkSend(b *testing.B) {
+	cfg := configWithNumConfs(1)
+	cfg.ReceiptQueryInterval = time.Millisecond
+	backend := newMockBackend()
+	mgr := newTxManager(b, cfg, backend)
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	var nonce atomic.Uint64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			txNonce := nonce.Add(1)
+			updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+				return types.NewTx(&types.DynamicFeeTx{
+					Nonce:     txNonce,
+					GasTipCap: big.NewInt(1),
+					GasFeeCap: big.NewInt(10),
+				}), nil
+			}
+			if _, err := mgr.Send(context.Background(), updateGasPrice, sendTx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "sends/s")
+}
+
+func BenchmarkWaitMined(b *testing.B) {
+	backend := newMockBackend()
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+	backend.mine(&txHash, new(big.Int))
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := txmgr.WaitMined(ctx, backend, tx, time.Millisecond, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,128 @@
+package txmgrtest
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+)
+
+// FakeReceiptSource 内存中的 txmgr.Backend，由测试控制出块和重组
+type FakeReceiptSource struct {
+	mu       sync.RWMutex
+	headers  []*types.Header // headers[i] 为块高 i+1 的区块头
+	receipts map[common.Hash]*types.Receipt
+	baseFee  *big.Int // 之后产出的块的 baseFee，为空时区块头不含 baseFee
+}
+
+var _ txmgr.Backend = (*FakeReceiptSource)(nil)
+
+func NewFakeReceiptSource() *FakeReceiptSource {
+	return &FakeReceiptSource{
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+}
+
+// Mine 产出一个包含给定交易的新块，返回新块高
+func (b *FakeReceiptSource) Mine(txHashes ...common.Hash) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	header := b.newHeader(uint64(len(b.headers)+1), nil)
+	b.headers = append(b.headers, header)
+	for i, txHash := range txHashes {
+		b.receipts[txHash] = &types.Receipt{
+			Status:           types.ReceiptStatusSuccessful,
+			TxHash:           txHash,
+			BlockHash:        header.Hash(),
+			BlockNumber:      new(big.Int).Set(header.Number),
+			TransactionIndex: uint(i),
+		}
+	}
+	return header.Number.Uint64()
+}
+
+// SetBaseFee 设置之后产出的块的 baseFee，nil 表示不含 baseFee（EIP-1559 之前的链）
+func (b *FakeReceiptSource) SetBaseFee(baseFee *big.Int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.baseFee = baseFee
+}
+
+// Reorg 移除交易的回执，模拟交易所在的块被重组掉
+func (b *FakeReceiptSource) Reorg(txHash common.Hash) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.receipts, txHash)
+}
+
+// ReorgBlock 替换指定块高的区块头但保留回执，模拟节点返回已被重组掉的块中的旧回执
+func (b *FakeReceiptSource) ReorgBlock(number uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == 0 || number > uint64(len(b.headers)) {
+		return
+	}
+	b.headers[number-1] = b.newHeader(number, []byte("reorg"))
+}
+
+func (b *FakeReceiptSource) newHeader(number uint64, extra []byte) *types.Header {
+	parentHash := common.Hash{}
+	if number > 1 {
+		parentHash = b.headers[number-2].Hash()
+	}
+	header := &types.Header{
+		ParentHash: parentHash,
+		Number:     new(big.Int).SetUint64(number),
+		Extra:      extra,
+	}
+	if b.baseFee != nil {
+		header.BaseFee = new(big.Int).Set(b.baseFee)
+	}
+	return header
+}
+
+func (b *FakeReceiptSource) BlockNumber(ctx context.Context) (uint64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return uint64(len(b.headers)), nil
+}
+
+// HeaderByNumber number 为 nil 或 safe、finalized 等特殊块高时返回最新区块头
+func (b *FakeReceiptSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.headers) == 0 {
+		return &types.Header{Number: new(big.Int)}, nil
+	}
+	if number == nil || number.Sign() < 0 {
+		return types.CopyHeader(b.headers[len(b.headers)-1]), nil
+	}
+	n := number.Uint64()
+	if n == 0 || n > uint64(len(b.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return types.CopyHeader(b.headers[n-1]), nil
+}
+
+func (b *FakeReceiptSource) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	receipt, ok := b.receipts[txHash]
+	if !ok {
+		return nil, nil
+	}
+	copied := *receipt
+	return &copied, nil
+}
@@ -0,0 +1,128 @@
+package txmgrtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+)
+
+// FakeClock 手动推进的 txmgr.Clock，定时器只在 Advance 时触发
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	period   time.Duration // 0 表示一次性定时器
+	ch       chan time.Time
+	stopped  bool
+}
+
+var _ txmgr.Clock = (*FakeClock)(nil)
+
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.addWaiter(d, 0).ch
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) txmgr.Ticker {
+	if d <= 0 {
+		panic("txmgrtest: non-positive interval for NewTicker")
+	}
+	return &fakeTicker{clock: c, waiter: c.addWaiter(d, d)}
+}
+
+func (c *FakeClock) addWaiter(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &fakeWaiter{
+		deadline: c.now.Add(d),
+		period:   period,
+		ch:       make(chan time.Time, 1),
+	}
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+	return w
+}
+
+// Advance 推进时间并触发所有到期的定时器。与 time.Ticker 一样，接收方来不及读取时丢弃多余的触发
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	active := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.stopped {
+			continue
+		}
+		if !w.deadline.After(c.now) {
+			select {
+			case w.ch <- c.now:
+			default:
+			}
+			if w.period == 0 {
+				continue
+			}
+			for !w.deadline.After(c.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+		}
+		active = append(active, w)
+	}
+	c.waiters = active
+}
+
+// BlockUntil 阻塞直到至少有 n 个未触发的定时器，用于确认被测代码已经开始等待
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.numWaiters() < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) numWaiters() int {
+	n := 0
+	for _, w := range c.waiters {
+		if !w.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.waiter.stopped = true
+}
@@ -0,0 +1,228 @@
+// Package txmgrtest 提供测试 txmgr 集成时使用的工具：内存中的 backend（FakeReceiptSource）、
+// 可手动推进的时钟（FakeClock）、记录并按需打包交易的发送函数（FakeSender），
+// 以及包装 backend 和发送函数注入 RPC 超时、重组、丢交易等故障的 Injector
+package txmgrtest
+
+import (
+	"errors"
+	"math/big"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+)
+
+// ErrInjectedTimeout 注入的 RPC 超时错误
+var ErrInjectedTimeout = errors.New("txmgrtest: injected rpc timeout")
+
+// Faults 各类故障发生的概率，取值 [0, 1]
+type Faults struct {
+	RPCTimeout  float64 // 查询块高、区块头、回执、nonce 或费用历史时返回超时
+	Reorg       float64 // 已打包交易的回执暂时消失，或查询到的区块头被替换，模拟重组
+	DropTx      float64 // 发送成功但交易被丢弃，不会进入链上
+	NonceTooLow float64 // 发送时返回 nonce 过低
+	Seed        uint64  // 随机数种子，相同种子得到相同的故障序列
+}
+
+// Injector 按 Faults 配置注入故障，可同时包装 backend 和发送函数
+type Injector struct {
+	faults Faults
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func NewInjector(faults Faults) *Injector {
+	return &Injector{
+		faults: faults,
+		rng:    rand.New(rand.NewPCG(faults.Seed, faults.Seed)),
+	}
+}
+
+func (i *Injector) hit(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.rng.Float64() < p
+}
+
+// WrapBackend 返回注入 RPC 超时和重组故障的 backend。backend 实现的 HeaderSource、
+// FeeHistoryReader 和 NonceSource 会被转发并同样注入故障，未实现的不会出现在返回值上，
+// 因此 txmgr 对配置的校验结果与直接使用 backend 时相同。返回值总是实现 BatchReceiptSource，
+// backend 不支持批量查询时逐笔查询
+func (i *Injector) WrapBackend(backend txmgr.ReceiptSource) txmgr.ReceiptSource {
+	b := &faultyBackend{backend: backend, injector: i}
+	headers := faultyHeaders{b}
+	fees := faultyFeeHistory{b}
+	nonces := faultyNonces{b}
+
+	_, hasHeaders := backend.(txmgr.HeaderSource)
+	_, hasFees := backend.(txmgr.FeeHistoryReader)
+	_, hasNonces := backend.(txmgr.NonceSource)
+	switch {
+	case hasHeaders && hasFees && hasNonces:
+		return struct {
+			*faultyBackend
+			faultyHeaders
+			faultyFeeHistory
+			faultyNonces
+		}{b, headers, fees, nonces}
+	case hasHeaders && hasFees:
+		return struct {
+			*faultyBackend
+			faultyHeaders
+			faultyFeeHistory
+		}{b, headers, fees}
+	case hasHeaders && hasNonces:
+		return struct {
+			*faultyBackend
+			faultyHeaders
+			faultyNonces
+		}{b, headers, nonces}
+	case hasFees && hasNonces:
+		return struct {
+			*faultyBackend
+			faultyFeeHistory
+			faultyNonces
+		}{b, fees, nonces}
+	case hasHeaders:
+		return struct {
+			*faultyBackend
+			faultyHeaders
+		}{b, headers}
+	case hasFees:
+		return struct {
+			*faultyBackend
+			faultyFeeHistory
+		}{b, fees}
+	case hasNonces:
+		return struct {
+			*faultyBackend
+			faultyNonces
+		}{b, nonces}
+	default:
+		return b
+	}
+}
+
+// WrapSend 返回注入 nonce 过低和交易丢弃故障的发送函数
+func (i *Injector) WrapSend(sendTx txmgr.SendTransactionFunc) txmgr.SendTransactionFunc {
+	return func(ctx context.Context, tx *types.Transaction) error {
+		if i.hit(i.faults.NonceTooLow) {
+			return core.ErrNonceTooLow
+		}
+		if i.hit(i.faults.DropTx) {
+			return nil
+		}
+		return sendTx(ctx, tx)
+	}
+}
+
+type faultyBackend struct {
+	backend  txmgr.ReceiptSource
+	injector *Injector
+}
+
+func (b *faultyBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	if b.injector.hit(b.injector.faults.RPCTimeout) {
+		return 0, ErrInjectedTimeout
+	}
+	return b.backend.BlockNumber(ctx)
+}
+
+func (b *faultyBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if b.injector.hit(b.injector.faults.RPCTimeout) {
+		return nil, ErrInjectedTimeout
+	}
+	receipt, err := b.backend.TransactionReceipt(ctx, txHash)
+	if receipt != nil && b.injector.hit(b.injector.faults.Reorg) {
+		return nil, nil
+	}
+	return receipt, err
+}
+
+func (b *faultyBackend) TransactionReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, []error) {
+	receipts := make([]*types.Receipt, len(txHashes))
+	errs := make([]error, len(txHashes))
+	if b.injector.hit(b.injector.faults.RPCTimeout) {
+		for i := range errs {
+			errs[i] = ErrInjectedTimeout
+		}
+		return receipts, errs
+	}
+
+	if batch, ok := b.backend.(txmgr.BatchReceiptSource); ok {
+		receipts, errs = batch.TransactionReceipts(ctx, txHashes)
+	} else {
+		for i, txHash := range txHashes {
+			receipts[i], errs[i] = b.backend.TransactionReceipt(ctx, txHash)
+		}
+	}
+	for i := range receipts {
+		if receipts[i] != nil && b.injector.hit(b.injector.faults.Reorg) {
+			receipts[i] = nil
+		}
+	}
+	return receipts, errs
+}
+
+type faultyHeaders struct {
+	b *faultyBackend
+}
+
+func (h faultyHeaders) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if h.b.injector.hit(h.b.injector.faults.RPCTimeout) {
+		return nil, ErrInjectedTimeout
+	}
+	header, err := h.b.backend.(txmgr.HeaderSource).HeaderByNumber(ctx, number)
+	if header != nil && h.b.injector.hit(h.b.injector.faults.Reorg) {
+		// 返回同一块高的另一个区块头，模拟该块已被重组替换
+		header = types.CopyHeader(header)
+		header.Extra = append(header.Extra, "txmgrtest: reorg"...)
+	}
+	return header, err
+}
+
+type faultyFeeHistory struct {
+	b *faultyBackend
+}
+
+func (f faultyFeeHistory) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+
+	if f.b.injector.hit(f.b.injector.faults.RPCTimeout) {
+		return nil, ErrInjectedTimeout
+	}
+	return f.b.backend.(txmgr.FeeHistoryReader).FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
+type faultyNonces struct {
+	b *faultyBackend
+}
+
+func (n faultyNonces) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	if n.b.injector.hit(n.b.injector.faults.RPCTimeout) {
+		return 0, ErrInjectedTimeout
+	}
+	return n.b.backend.(txmgr.NonceSource).NonceAt(ctx, account, blockNumber)
+}
+
+func (n faultyNonces) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if n.b.injector.hit(n.b.injector.faults.RPCTimeout) {
+		return 0, ErrInjectedTimeout
+	}
+	return n.b.backend.(txmgr.NonceSource).PendingNonceAt(ctx, account)
+}
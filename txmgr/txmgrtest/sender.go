@@ -0,0 +1,62 @@
+package txmgrtest
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/net/context"
+
+	"github.com/DQYXACML/dapplink-vrf/txmgr"
+)
+
+// FakeSender 记录发送的交易，可选择发送时立即打包。Send 方法可直接作为 txmgr.SendTransactionFunc
+type FakeSender struct {
+	backend    *FakeReceiptSource
+	mineOnSend bool
+
+	mu   sync.Mutex
+	sent []*types.Transaction
+	err  error
+}
+
+var _ txmgr.SendTransactionFunc = (*FakeSender)(nil).Send
+
+func NewFakeSender(backend *FakeReceiptSource, mineOnSend bool) *FakeSender {
+	return &FakeSender{
+		backend:    backend,
+		mineOnSend: mineOnSend,
+	}
+}
+
+// SetError 之后的发送都返回 err，传入 nil 恢复正常
+func (s *FakeSender) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Sent 返回所有发送成功的交易
+func (s *FakeSender) Sent() []*types.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*types.Transaction(nil), s.sent...)
+}
+
+func (s *FakeSender) Send(ctx context.Context, tx *types.Transaction) error {
+	s.mu.Lock()
+	err := s.err
+	if err == nil {
+		s.sent = append(s.sent, tx)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if s.mineOnSend && s.backend != nil {
+		s.backend.Mine(tx.Hash())
+	}
+	return nil
+}